@@ -0,0 +1,127 @@
+package nanoleaf
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrMalformedAnimation is returned if animation data can't be parsed
+	ErrMalformedAnimation = errors.New("malformed animation data")
+)
+
+// Animation is the parsed form of the animation data used by custom and static effects
+type Animation struct {
+	Panels []PanelAnimation
+}
+
+// PanelAnimation contains the keyframes shown on a single panel
+type PanelAnimation struct {
+	PanelID int
+	Frames  []Keyframe
+}
+
+// Keyframe is a single colour in the animation of a panel
+type Keyframe struct {
+	// Red, Green, Blue and White are 0-255 values
+	Red   int
+	Green int
+	Blue  int
+	White int
+	// TransitionTime is the time to fade to this colour, in multiples of 100ms
+	TransitionTime int
+}
+
+// ParseAnimation decodes animation data of the form "numPanels panelId numFrames R G B W T ... panelId numFrames ..."
+func ParseAnimation(animData string) (*Animation, error) {
+	fields := strings.Fields(animData)
+	pos := 0
+
+	next := func(name string, min int, max int) (int, error) {
+		if pos >= len(fields) {
+			return 0, fmt.Errorf("%w: missing %s", ErrMalformedAnimation, name)
+		}
+
+		v, err := strconv.Atoi(fields[pos])
+		if err != nil {
+			return 0, fmt.Errorf("%w: %s %q is not a number", ErrMalformedAnimation, name, fields[pos])
+		} else if v < min || v > max {
+			return 0, fmt.Errorf("%w: %s %d is out of range", ErrMalformedAnimation, name, v)
+		}
+
+		pos++
+		return v, nil
+	}
+
+	panelCount, err := next("panel count", 0, 65535)
+	if err != nil {
+		return nil, err
+	}
+
+	anim := &Animation{}
+	for i := 0; i < panelCount; i++ {
+		panel := PanelAnimation{}
+		if panel.PanelID, err = next("panel ID", 0, 65535); err != nil {
+			return nil, err
+		}
+
+		frameCount, err := next("frame count", 0, 65535)
+		if err != nil {
+			return nil, err
+		}
+
+		for j := 0; j < frameCount; j++ {
+			frame := Keyframe{}
+			if frame.Red, err = next("red", 0, 255); err != nil {
+				return nil, err
+			} else if frame.Green, err = next("green", 0, 255); err != nil {
+				return nil, err
+			} else if frame.Blue, err = next("blue", 0, 255); err != nil {
+				return nil, err
+			} else if frame.White, err = next("white", 0, 255); err != nil {
+				return nil, err
+			} else if frame.TransitionTime, err = next("transition time", 0, 65535); err != nil {
+				return nil, err
+			}
+
+			panel.Frames = append(panel.Frames, frame)
+		}
+
+		anim.Panels = append(anim.Panels, panel)
+	}
+
+	if pos != len(fields) {
+		return nil, fmt.Errorf("%w: %d unexpected trailing values", ErrMalformedAnimation, len(fields)-pos)
+	}
+
+	return anim, nil
+}
+
+// String encodes the animation in the animation data format expected by the panel
+func (a *Animation) String() string {
+	var b strings.Builder
+
+	b.WriteString(strconv.Itoa(len(a.Panels)))
+	for _, panel := range a.Panels {
+		b.WriteString(" " + strconv.Itoa(panel.PanelID) + " " + strconv.Itoa(len(panel.Frames)))
+		for _, frame := range panel.Frames {
+			for _, v := range []int{frame.Red, frame.Green, frame.Blue, frame.White, frame.TransitionTime} {
+				b.WriteString(" " + strconv.Itoa(v))
+			}
+		}
+	}
+
+	return b.String()
+}
+
+// Animation parses the animation data of this effect
+func (e *Effect) Animation() (*Animation, error) {
+	return ParseAnimation(e.AnimationData)
+}
+
+// SetAnimation encodes the specified animation as the animation data of this effect
+func (e *Effect) SetAnimation(anim *Animation) {
+	e.AnimationData = anim.String()
+}
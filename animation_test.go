@@ -0,0 +1,124 @@
+package nanoleaf_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/rmrobinson/nanoleaf-go"
+)
+
+func TestParseAnimation(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		expected *nanoleaf.Animation
+	}{
+		{
+			name: "static",
+			data: "2 101 1 255 0 0 0 1 102 1 0 0 255 0 1",
+			expected: &nanoleaf.Animation{Panels: []nanoleaf.PanelAnimation{
+				{PanelID: 101, Frames: []nanoleaf.Keyframe{{Red: 255, TransitionTime: 1}}},
+				{PanelID: 102, Frames: []nanoleaf.Keyframe{{Blue: 255, TransitionTime: 1}}},
+			}},
+		},
+		{
+			name: "multiple frames",
+			data: "1 65535 2 1 2 3 4 5 255 255 255 255 65535",
+			expected: &nanoleaf.Animation{Panels: []nanoleaf.PanelAnimation{
+				{PanelID: 65535, Frames: []nanoleaf.Keyframe{
+					{Red: 1, Green: 2, Blue: 3, White: 4, TransitionTime: 5},
+					{Red: 255, Green: 255, Blue: 255, White: 255, TransitionTime: 65535},
+				}},
+			}},
+		},
+		{
+			name:     "no panels",
+			data:     "0",
+			expected: &nanoleaf.Animation{},
+		},
+		{
+			name: "extra whitespace",
+			data: "  1\t101 1\n0 0 0 0 0 ",
+			expected: &nanoleaf.Animation{Panels: []nanoleaf.PanelAnimation{
+				{PanelID: 101, Frames: []nanoleaf.Keyframe{{}}},
+			}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			anim, err := nanoleaf.ParseAnimation(test.data)
+			if err != nil {
+				t.Fatalf("error parsing: %s", err)
+			}
+			if !reflect.DeepEqual(anim, test.expected) {
+				t.Fatalf("expected %+v, got %+v", test.expected, anim)
+			}
+		})
+	}
+}
+
+func TestParseAnimationMalformed(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"empty", ""},
+		{"not a number", "one"},
+		{"negative panel count", "-1"},
+		{"missing panel", "1"},
+		{"missing frame count", "1 101"},
+		{"missing frame values", "1 101 1 255 0 0 0"},
+		{"too few panels", "2 101 1 255 0 0 0 1"},
+		{"trailing values", "1 101 1 255 0 0 0 1 7"},
+		{"colour out of range", "1 101 1 256 0 0 0 1"},
+		{"negative colour", "1 101 1 0 -1 0 0 1"},
+		{"panel ID out of range", "1 65536 1 0 0 0 0 1"},
+		{"transition time out of range", "1 101 1 0 0 0 0 65536"},
+		{"fractional value", "1 101 1 0.5 0 0 0 1"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := nanoleaf.ParseAnimation(test.data); !errors.Is(err, nanoleaf.ErrMalformedAnimation) {
+				t.Fatalf("expected ErrMalformedAnimation, got %v", err)
+			}
+		})
+	}
+}
+
+func TestAnimationRoundTrip(t *testing.T) {
+	for _, data := range []string{
+		"0",
+		"1 101 1 255 0 0 0 1",
+		"3 1 2 10 20 30 0 5 40 50 60 0 10 2 0 3 2 255 255 255 255 65535 0 0 0 0 0",
+	} {
+		anim, err := nanoleaf.ParseAnimation(data)
+		if err != nil {
+			t.Fatalf("error parsing %q: %s", data, err)
+		}
+		if encoded := anim.String(); encoded != data {
+			t.Fatalf("expected %q to encode unchanged, got %q", data, encoded)
+		}
+	}
+
+	anim := &nanoleaf.Animation{Panels: []nanoleaf.PanelAnimation{
+		{PanelID: 7, Frames: []nanoleaf.Keyframe{{Red: 1, Green: 2, Blue: 3, White: 4, TransitionTime: 5}}},
+		{PanelID: 8, Frames: []nanoleaf.Keyframe{{Red: 9, TransitionTime: 10}, {Blue: 11}}},
+	}}
+
+	effect := &nanoleaf.Effect{}
+	effect.SetAnimation(anim)
+	if effect.AnimationData != "2 7 1 1 2 3 4 5 8 2 9 0 0 0 10 0 0 11 0 0" {
+		t.Fatalf("unexpected animation data %q", effect.AnimationData)
+	}
+
+	parsed, err := effect.Animation()
+	if err != nil {
+		t.Fatalf("error parsing: %s", err)
+	}
+	if !reflect.DeepEqual(parsed, anim) {
+		t.Fatalf("expected %+v, got %+v", anim, parsed)
+	}
+}
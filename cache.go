@@ -0,0 +1,212 @@
+package nanoleaf
+
+import (
+	"context"
+	"reflect"
+	"sync"
+)
+
+// StateCache keeps a copy of the panel details up to date by applying the updates received from the event stream,
+// so the current state can be read without making a request to the panel.
+// Changes made through the cache are sent to the panel and applied to the cache immediately.
+type StateCache struct {
+	client *Client
+
+	lock      sync.RWMutex
+	panel     LightPanel
+	listeners []func(PanelUpdate)
+}
+
+// NewStateCache retrieves the panel details and keeps them up to date until the context is cancelled.
+// The event stream is reconnected as described by the options if it is lost.
+func NewStateCache(ctx context.Context, client *Client, opts ReconnectOptions) (*StateCache, error) {
+	// The stream may not be open yet when the state is retrieved, so the full state is delivered again once it is
+	// to cover any changes made in between
+	ctx, cancel := context.WithCancel(ctx)
+	updates := client.subscribeWithReconnect(ctx, opts, true, EventTypeState, EventTypeLayout, EventTypeEffects)
+
+	panel, err := client.GetPanel(ctx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	sc := &StateCache{
+		client: client,
+		panel:  *panel,
+	}
+
+	go func() {
+		defer cancel()
+
+		for update := range updates {
+			sc.apply(update)
+		}
+	}()
+
+	return sc, nil
+}
+
+// Snapshot returns a copy of the current panel details
+func (sc *StateCache) Snapshot() LightPanel {
+	sc.lock.RLock()
+	defer sc.lock.RUnlock()
+
+	return copyPanel(sc.panel)
+}
+
+// OnChange registers a function which is called after each update which changes the cached details.
+// Updates which leave the details as they were, such as resyncs after reconnecting, are not reported.
+// It is called synchronously, so should not block.
+func (sc *StateCache) OnChange(fn func(PanelUpdate)) {
+	sc.lock.Lock()
+	defer sc.lock.Unlock()
+
+	sc.listeners = append(sc.listeners, fn)
+}
+
+// UpdateState applies the update to the panel and, if successful, to the cache.
+// Increments are only reflected in the cache once the panel reports the resulting value.
+func (sc *StateCache) UpdateState(ctx context.Context, update StateUpdate) error {
+	if err := sc.client.UpdateState(ctx, update); err != nil {
+		return err
+	}
+
+	state := &PanelState{}
+	if update.on != nil {
+		state.On = &BoolValue{Value: update.on.Value}
+	}
+	for _, change := range []struct {
+		from *stateChange
+		to   **IntRangeValue
+	}{
+		{update.brightness, &state.Brightness},
+		{update.hue, &state.Hue},
+		{update.saturation, &state.Saturation},
+		{update.ct, &state.CT},
+	} {
+		if change.from != nil && change.from.Value != nil {
+			*change.to = &IntRangeValue{Value: *change.from.Value}
+		}
+	}
+
+	sc.apply(PanelUpdate{TypeID: int(EventTypeState), State: state})
+	return nil
+}
+
+// SetScene selects the scene on the panel and, if successful, in the cache
+func (sc *StateCache) SetScene(ctx context.Context, sceneName string) error {
+	if err := sc.client.SetScene(ctx, sceneName); err != nil {
+		return err
+	}
+
+	sc.apply(PanelUpdate{TypeID: int(EventTypeEffects), Effect: &PanelEffect{Current: sceneName}})
+	return nil
+}
+
+func (sc *StateCache) apply(update PanelUpdate) {
+	sc.lock.Lock()
+	before := copyPanel(sc.panel)
+
+	switch {
+	case update.State != nil:
+		sc.applyState(update.State, update.Synthetic)
+	case update.Layout != nil:
+		// Event updates only contain the attributes which changed, so the zero value indicates an attribute wasn't sent
+		if update.Synthetic || len(update.Layout.Panels.Panels) > 0 || update.Layout.Panels.PanelCount > 0 {
+			sc.panel.Layout.Panels = update.Layout.Panels
+		}
+		if update.Synthetic || update.Layout.Orientation != (IntRangeValue{}) {
+			mergeRange(&sc.panel.Layout.Orientation, &update.Layout.Orientation, update.Synthetic)
+		}
+	case update.Effect != nil:
+		if update.Synthetic {
+			sc.panel.Effect = *update.Effect
+		} else if len(update.Effect.Current) > 0 {
+			sc.panel.Effect.Current = update.Effect.Current
+		}
+	}
+
+	// Resyncs and events repeating the current values don't need to be reported
+	if reflect.DeepEqual(before, copyPanel(sc.panel)) {
+		sc.lock.Unlock()
+		return
+	}
+
+	listeners := sc.listeners
+	sc.lock.Unlock()
+
+	for _, fn := range listeners {
+		fn(update)
+	}
+}
+
+func (sc *StateCache) applyState(state *PanelState, replace bool) {
+	current := &sc.panel.State
+
+	if state.On != nil {
+		current.On = &BoolValue{Value: state.On.Value}
+	}
+	for _, field := range []struct {
+		from *IntRangeValue
+		to   **IntRangeValue
+	}{
+		{state.Brightness, &current.Brightness},
+		{state.Hue, &current.Hue},
+		{state.Saturation, &current.Saturation},
+		{state.CT, &current.CT},
+	} {
+		if field.from == nil {
+			continue
+		}
+		if *field.to == nil {
+			*field.to = &IntRangeValue{}
+		}
+		mergeRange(*field.to, field.from, replace)
+	}
+	if state.ColorMode != nil {
+		mode := *state.ColorMode
+		current.ColorMode = &mode
+	}
+}
+
+// mergeRange updates the value, keeping the existing limits unless they are being replaced
+func mergeRange(to *IntRangeValue, from *IntRangeValue, replace bool) {
+	if replace {
+		*to = *from
+		return
+	}
+	to.Value = from.Value
+}
+
+// copyPanel deep copies the panel so the copy can be used without holding the lock
+func copyPanel(panel LightPanel) LightPanel {
+	cp := panel
+
+	if panel.State.On != nil {
+		on := *panel.State.On
+		cp.State.On = &on
+	}
+	for _, field := range []struct {
+		from *IntRangeValue
+		to   **IntRangeValue
+	}{
+		{panel.State.Brightness, &cp.State.Brightness},
+		{panel.State.Hue, &cp.State.Hue},
+		{panel.State.Saturation, &cp.State.Saturation},
+		{panel.State.CT, &cp.State.CT},
+	} {
+		if field.from != nil {
+			v := *field.from
+			*field.to = &v
+		}
+	}
+	if panel.State.ColorMode != nil {
+		mode := *panel.State.ColorMode
+		cp.State.ColorMode = &mode
+	}
+
+	cp.Effect.Options = append([]string(nil), panel.Effect.Options...)
+	cp.Layout.Panels.Panels = append([]PanelPosition(nil), panel.Layout.Panels.Panels...)
+	return cp
+}
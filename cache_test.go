@@ -0,0 +1,142 @@
+package nanoleaf_test
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rmrobinson/nanoleaf-go"
+	"github.com/rmrobinson/nanoleaf-go/nanoleaftest"
+)
+
+// waitForSnapshot polls the cache until the condition holds, failing the test if it doesn't in time
+func waitForSnapshot(t *testing.T, cache *nanoleaf.StateCache, cond func(nanoleaf.LightPanel) bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond(cache.Snapshot()) {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for cache, have %+v", cache.Snapshot())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestStateCacheResyncsOnFirstConnect(t *testing.T) {
+	controller := nanoleaftest.NewController()
+	defer controller.Close()
+
+	// Keep the stream from opening until after the state has been retrieved
+	controller.Fail(http.MethodGet, "events", http.StatusServiceUnavailable, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cache, err := nanoleaf.NewStateCache(ctx, controller.Client(), nanoleaf.ReconnectOptions{MinBackoff: 200 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("error creating cache: %s", err)
+	}
+
+	// The change is made without an event being sent, so is only picked up by the resync
+	panel := controller.Panel()
+	panel.State.Brightness = &nanoleaf.IntRangeValue{Value: 17, Max: 100}
+	controller.SetPanel(panel)
+
+	waitForSnapshot(t, cache, func(panel nanoleaf.LightPanel) bool {
+		return panel.State.Brightness.Value == 17
+	})
+}
+
+func TestStateCacheAppliesEvents(t *testing.T) {
+	controller := nanoleaftest.NewController()
+	defer controller.Close()
+	client := controller.Client()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cache, err := nanoleaf.NewStateCache(ctx, client, nanoleaf.ReconnectOptions{MinBackoff: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("error creating cache: %s", err)
+	}
+	waitForCacheStream(t, client, cache)
+
+	if err = client.SetScene(ctx, "Forest"); err != nil {
+		t.Fatalf("error setting scene: %s", err)
+	}
+	waitForSnapshot(t, cache, func(panel nanoleaf.LightPanel) bool {
+		return panel.Effect.Current == "Forest"
+	})
+}
+
+func TestStateCacheOnlyReportsChanges(t *testing.T) {
+	controller := nanoleaftest.NewController()
+	defer controller.Close()
+	client := controller.Client()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cache, err := nanoleaf.NewStateCache(ctx, client, nanoleaf.ReconnectOptions{MinBackoff: 10 * time.Millisecond, MaxBackoff: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("error creating cache: %s", err)
+	}
+	waitForCacheStream(t, client, cache)
+
+	var (
+		lock    sync.Mutex
+		changes []nanoleaf.PanelUpdate
+	)
+	cache.OnChange(func(update nanoleaf.PanelUpdate) {
+		lock.Lock()
+		defer lock.Unlock()
+		changes = append(changes, update)
+	})
+
+	// The resync after reconnecting doesn't change anything
+	controller.EndStreams()
+	time.Sleep(300 * time.Millisecond)
+
+	lock.Lock()
+	if len(changes) > 0 {
+		t.Fatalf("expected no changes to be reported, got %+v", changes)
+	}
+	lock.Unlock()
+
+	if err = client.SetOn(ctx, false); err != nil {
+		t.Fatalf("error turning off: %s", err)
+	}
+	waitForSnapshot(t, cache, func(panel nanoleaf.LightPanel) bool {
+		return !panel.State.On.Value
+	})
+
+	lock.Lock()
+	defer lock.Unlock()
+	if len(changes) != 1 || changes[0].State == nil || changes[0].State.On == nil || changes[0].State.On.Value {
+		t.Fatalf("expected only the off event to be reported, got %+v", changes)
+	}
+}
+
+// waitForCacheStream changes the brightness until the cache reflects it, showing the event stream is open
+func waitForCacheStream(t *testing.T, client *nanoleaf.Client, cache *nanoleaf.StateCache) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for brightness := 1; ; brightness++ {
+		if err := client.SetBrightness(context.Background(), brightness, 0); err != nil {
+			t.Fatalf("error setting brightness: %s", err)
+		}
+
+		for end := time.Now().Add(100 * time.Millisecond); time.Now().Before(end); {
+			if panel := cache.Snapshot(); panel.State.Brightness != nil && panel.State.Brightness.Value == brightness {
+				return
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("event stream never opened")
+		}
+	}
+}
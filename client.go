@@ -5,8 +5,10 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"io"
 	"net/http"
-	"strconv"
+	"sync"
+	"time"
 )
 
 var (
@@ -25,145 +27,197 @@ var (
 // Client represents a handle to the Nanoleaf API
 type Client struct {
 	httpClient *http.Client
-	apiKey     string
-	hostname   string
-	port       int
+	// baseURL is the scheme and host (plus any path prefix) the API is served from
+	baseURL string
+	// hostname is the host of the panel, used for the streaming protocol
+	hostname  string
+	userAgent string
+	logger    Logger
+
+	retryPolicy RetryPolicy
+	timeout     time.Duration
+
+	// apiKey is guarded by the lock as it can be replaced when rotating keys
+	lock   sync.RWMutex
+	apiKey string
 }
 
-// NewClient creates a new Nanoleaf API client
+// NewClient creates a new Nanoleaf API client. It is equivalent to calling New with
+// the WithHTTPClient, WithPort and WithAPIKey options, except an empty hostname isn't rejected.
 func NewClient(httpClient *http.Client, hostname string, port int, apiKey string) *Client {
+	// Only an invalid base URL can fail, which isn't possible here
+	c, _ := newClient(hostname, newOptions([]Option{WithHTTPClient(httpClient), WithPort(port), WithAPIKey(apiKey)}))
+	return c
+}
+
+// withAPIKey returns a copy of the client which uses the specified API key
+func (c *Client) withAPIKey(apiKey string) *Client {
 	return &Client{
-		httpClient: httpClient,
-		hostname:   hostname,
-		port:       port,
-		apiKey:     apiKey,
+		httpClient:  c.httpClient,
+		baseURL:     c.baseURL,
+		hostname:    c.hostname,
+		userAgent:   c.userAgent,
+		logger:      c.logger,
+		retryPolicy: c.retryPolicy,
+		timeout:     c.timeout,
+		apiKey:      apiKey,
 	}
 }
 
+// APIKey returns the API key currently used by the client
+func (c *Client) APIKey() string {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.apiKey
+}
+
+func (c *Client) getURLRoot() string {
+	return c.baseURL + "/api/v1/"
+}
+
 func (c *Client) getURLBase() string {
-	return "http://" + c.hostname + ":" + strconv.Itoa(c.port) + "/api/v1/" + c.apiKey + "/"
+	return c.getURLRoot() + c.APIKey() + "/"
 }
 
 // CreateAPIKey is used to create a new API key (after pressing the 'pair' button on the panel)
 func (c *Client) CreateAPIKey(ctx context.Context) (string, error) {
-	r, err := http.NewRequest(http.MethodPost, "http://"+c.hostname+":"+strconv.Itoa(c.port)+"/api/v1/new", nil)
+	var nanoleafResp struct {
+		AuthToken string `json:"auth_token"`
+	}
+
+	err := c.do(ctx, http.MethodPost, c.getURLRoot()+"new", false, nil, &nanoleafResp)
 	if err != nil {
 		return "", err
 	}
 
-	r = r.WithContext(ctx)
+	return nanoleafResp.AuthToken, nil
+}
 
-	resp, err := c.httpClient.Do(r)
+// DeleteAPIKey is used to remove an existing API key. The key being deleted is also used to authorize the request,
+// so it is not retried: once the key has been deleted a retry would fail as unauthorized.
+func (c *Client) DeleteAPIKey(ctx context.Context, keyToDelete string) error {
+	return c.do(ctx, http.MethodDelete, c.getURLRoot()+keyToDelete, false, nil, nil)
+}
+
+// RotateAPIKey replaces the API key used by the client with a newly created one.
+// The new key is created once the pairing window is opened (see Pair) and verified before the client switches to it;
+// the previous key is then deleted. If deleting the previous key fails the new key is still returned, as it is in use.
+func (c *Client) RotateAPIKey(ctx context.Context, opts PairOptions) (string, error) {
+	newKey, err := c.Pair(ctx, opts)
 	if err != nil {
 		return "", err
 	}
-	defer resp.Body.Close()
 
-	var nanoleafResp struct {
-		AuthToken string `json:"auth_token"`
+	verifier := c.withAPIKey(newKey)
+	if _, err = verifier.GetPanel(ctx); err != nil {
+		// Don't leave an unused key behind
+		verifier.DeleteAPIKey(ctx, newKey)
+		return "", err
 	}
 
-	if resp.StatusCode == 200 {
-		err = json.NewDecoder(resp.Body).Decode(&nanoleafResp)
-		if err != nil {
-			return "", err
-		}
+	c.lock.Lock()
+	oldKey := c.apiKey
+	c.apiKey = newKey
+	c.lock.Unlock()
 
-		return nanoleafResp.AuthToken, nil
-	} else if resp.StatusCode == 401 {
-		return "", ErrUnauthorized
-	} else if resp.StatusCode == 403 {
-		return "", ErrForbidden
-	} else if resp.StatusCode == 422 {
-		return "", ErrBadRequest
+	if err = c.DeleteAPIKey(ctx, oldKey); err != nil {
+		return newKey, err
 	}
+	return newKey, nil
+}
 
-	return "", ErrUnknown
+func (c *Client) get(ctx context.Context, path string, respType interface{}) error {
+	return c.do(ctx, http.MethodGet, c.getURLBase()+path, true, nil, respType)
 }
 
-// DeleteAPIKey is used to remove an existing API key
-func (c *Client) DeleteAPIKey(ctx context.Context, keyToDelete string) error {
-	r, err := http.NewRequest(http.MethodDelete, c.getURLBase(), nil)
-	if err != nil {
-		return err
+func (c *Client) put(ctx context.Context, path string, reqType interface{}, respType interface{}) error {
+	return c.do(ctx, http.MethodPut, c.getURLBase()+path, true, reqType, respType)
+}
+
+// putNonIdempotent is used for updates which are relative to the current state, so must not be blindly retried
+func (c *Client) putNonIdempotent(ctx context.Context, path string, reqType interface{}, respType interface{}) error {
+	return c.do(ctx, http.MethodPut, c.getURLBase()+path, false, reqType, respType)
+}
+
+// do sends the request, serializing reqType as the body if set, and decodes a successful response into respType if set.
+// Failed attempts are retried according to the retry policy of the client; unsuccessful responses are returned as an *APIError.
+func (c *Client) do(ctx context.Context, method string, url string, idempotent bool, reqType interface{}, respType interface{}) error {
+	var req []byte
+	if reqType != nil {
+		var err error
+		if req, err = json.Marshal(reqType); err != nil {
+			return err
+		}
 	}
 
-	r = r.WithContext(ctx)
+	for attempt := 0; ; attempt++ {
+		err := c.attempt(ctx, method, url, req, respType)
+		if err == nil || attempt+1 >= c.retryPolicy.MaxAttempts || ctx.Err() != nil || !c.retryPolicy.shouldRetry(err, idempotent) {
+			return err
+		}
 
-	resp, err := c.httpClient.Do(r)
-	if err != nil {
-		return err
+		delay := c.retryPolicy.backoff(attempt)
+		c.logf("nanoleaf: %s %s failed (attempt %d), retrying in %s: %s", method, redactURL(url), attempt+1, delay, err.Error())
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return err
+		}
 	}
-	defer resp.Body.Close()
+}
 
-	if resp.StatusCode == 204 {
-		return nil
-	} else if resp.StatusCode == 401 {
-		return ErrUnauthorized
+func (c *Client) attempt(ctx context.Context, method string, url string, req []byte, respType interface{}) error {
+	if _, ok := ctx.Deadline(); !ok && c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
 	}
 
-	return ErrUnknown
-}
+	var body io.Reader
+	if req != nil {
+		body = bytes.NewReader(req)
+	}
 
-func (c *Client) get(ctx context.Context, path string, respType interface{}) error {
-	r, err := http.NewRequest(http.MethodGet, c.getURLBase()+path, nil)
+	r, err := c.newRequest(ctx, method, url, body)
 	if err != nil {
 		return err
 	}
 
-	r = r.WithContext(ctx)
-
 	resp, err := c.httpClient.Do(r)
 	if err != nil {
-		return err
+		return redactTransportError(err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode == 200 {
+		if respType == nil {
+			return nil
+		}
 		return json.NewDecoder(resp.Body).Decode(respType)
-	} else if resp.StatusCode == 400 {
-		return ErrBadRequest
-	} else if resp.StatusCode == 401 {
-		return ErrUnauthorized
-	} else if resp.StatusCode == 404 {
-		return ErrNotFound
+	} else if resp.StatusCode == 204 {
+		return nil
 	}
 
-	return ErrUnknown
+	return newAPIError(r, resp)
 }
 
-func (c *Client) put(ctx context.Context, path string, reqType interface{}, respType interface{}) error {
-	req, err := json.Marshal(reqType)
+func (c *Client) newRequest(ctx context.Context, method string, url string, body io.Reader) (*http.Request, error) {
+	r, err := http.NewRequest(method, url, body)
 	if err != nil {
-		return err
-	}
-
-	r, err := http.NewRequest(http.MethodPut, c.getURLBase()+path, bytes.NewBuffer(req))
-	if err != nil {
-		return err
+		return nil, err
 	}
 
 	r = r.WithContext(ctx)
-
-	resp, err := c.httpClient.Do(r)
-	if err != nil {
-		return err
+	if len(c.userAgent) > 0 {
+		r.Header.Set("User-Agent", c.userAgent)
 	}
-	defer resp.Body.Close()
+	return r, nil
+}
 
-	if resp.StatusCode == 200 {
-		return json.NewDecoder(resp.Body).Decode(respType)
-	} else if resp.StatusCode == 204 {
-		return nil
-	} else if resp.StatusCode == 400 {
-		return ErrBadRequest
-	} else if resp.StatusCode == 401 {
-		return ErrUnauthorized
-	} else if resp.StatusCode == 404 {
-		return ErrNotFound
-	} else if resp.StatusCode == 422 {
-		return ErrBadRequest
+func (c *Client) logf(format string, v ...interface{}) {
+	if c.logger != nil {
+		c.logger.Printf(format, v...)
 	}
-
-	return ErrUnknown
 }
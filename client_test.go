@@ -0,0 +1,255 @@
+package nanoleaf_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rmrobinson/nanoleaf-go"
+	"github.com/rmrobinson/nanoleaf-go/nanoleaftest"
+)
+
+func TestStatusErrors(t *testing.T) {
+	tests := []struct {
+		status int
+		err    error
+	}{
+		{http.StatusBadRequest, nanoleaf.ErrBadRequest},
+		{http.StatusUnauthorized, nanoleaf.ErrUnauthorized},
+		{http.StatusForbidden, nanoleaf.ErrForbidden},
+		{http.StatusNotFound, nanoleaf.ErrNotFound},
+		{http.StatusUnprocessableEntity, nanoleaf.ErrBadRequest},
+		{http.StatusInternalServerError, nanoleaf.ErrUnknown},
+		{http.StatusServiceUnavailable, nanoleaf.ErrUnknown},
+	}
+
+	controller := nanoleaftest.NewController()
+	defer controller.Close()
+	client := controller.Client(nanoleaf.WithRetryPolicy(nanoleaf.RetryPolicy{MaxAttempts: 1}))
+
+	for _, test := range tests {
+		t.Run(http.StatusText(test.status), func(t *testing.T) {
+			controller.Fail(http.MethodGet, "", test.status, 1)
+
+			_, err := client.GetPanel(context.Background())
+			if !errors.Is(err, test.err) {
+				t.Fatalf("expected %v, got %v", test.err, err)
+			}
+
+			var apiErr *nanoleaf.APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("expected an *APIError, got %T", err)
+			}
+			if apiErr.StatusCode != test.status || apiErr.Method != http.MethodGet || apiErr.Body != http.StatusText(test.status) {
+				t.Fatalf("unexpected error details %+v", apiErr)
+			}
+			if strings.Contains(apiErr.Path, nanoleaftest.DefaultAPIKey) || strings.Contains(err.Error(), nanoleaftest.DefaultAPIKey) {
+				t.Fatalf("expected the API key to be redacted, got %s", err)
+			}
+		})
+	}
+}
+
+func TestStatusErrorsFromController(t *testing.T) {
+	controller := nanoleaftest.NewController()
+	defer controller.Close()
+	ctx := context.Background()
+
+	_, err := controller.Client(nanoleaf.WithAPIKey("invalid")).GetPanel(ctx)
+	if !errors.Is(err, nanoleaf.ErrUnauthorized) {
+		t.Fatalf("expected ErrUnauthorized for an unknown key, got %v", err)
+	}
+
+	_, err = controller.Client().CreateAPIKey(ctx)
+	if !errors.Is(err, nanoleaf.ErrForbidden) {
+		t.Fatalf("expected ErrForbidden while pairing is closed, got %v", err)
+	}
+
+	_, err = controller.Client().GetEffect(ctx, "Missing")
+	if !errors.Is(err, nanoleaf.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for an unknown effect, got %v", err)
+	}
+
+	err = controller.Client().AddEffect(ctx, &nanoleaf.Effect{})
+	if !errors.Is(err, nanoleaf.ErrBadRequest) {
+		t.Fatalf("expected ErrBadRequest for an effect without a name, got %v", err)
+	}
+}
+
+func TestPutNoContent(t *testing.T) {
+	controller := nanoleaftest.NewController()
+	defer controller.Close()
+	client := controller.Client()
+	ctx := context.Background()
+
+	// The controller responds to each of these with 204 No Content
+	if err := client.SetOn(ctx, false); err != nil {
+		t.Fatalf("error turning off: %s", err)
+	}
+	if err := client.SetBrightness(ctx, 30, 0); err != nil {
+		t.Fatalf("error setting brightness: %s", err)
+	}
+	if err := client.SetScene(ctx, "Forest"); err != nil {
+		t.Fatalf("error setting scene: %s", err)
+	}
+	if err := client.Identify(ctx); err != nil {
+		t.Fatalf("error identifying: %s", err)
+	}
+
+	panel := controller.Panel()
+	if panel.State.On.Value || panel.State.Brightness.Value != 30 || panel.Effect.Current != "Forest" || controller.IdentifyCount() != 1 {
+		t.Fatalf("expected the updates to be applied, got %+v", panel)
+	}
+}
+
+func TestPutNoContentWithResponse(t *testing.T) {
+	status := http.StatusNoContent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	client, err := nanoleaf.New("", nanoleaf.WithBaseURL(server.URL), nanoleaf.WithAPIKey("key"))
+	if err != nil {
+		t.Fatalf("error creating client: %s", err)
+	}
+
+	// A 204 is successful even when a response body is expected, leaving the response empty
+	effect, err := client.GetEffect(context.Background(), "Flames")
+	if err != nil {
+		t.Fatalf("expected no error for 204, got %s", err)
+	}
+	if effect.Name != "" {
+		t.Fatalf("expected an empty effect, got %+v", effect)
+	}
+
+	// Whereas a 200 must include the body
+	status = http.StatusOK
+	if _, err = client.GetEffect(context.Background(), "Flames"); err == nil {
+		t.Fatal("expected an error decoding an empty 200 response")
+	}
+}
+
+func TestGetPanel(t *testing.T) {
+	controller := nanoleaftest.NewController()
+	defer controller.Close()
+
+	panel, err := controller.Client().GetPanel(context.Background())
+	if err != nil {
+		t.Fatalf("error getting panel: %s", err)
+	}
+
+	expected := controller.Panel()
+	if panel.SerialNumber != expected.SerialNumber || panel.ModelNumber != expected.ModelNumber || panel.Effect.Current != expected.Effect.Current {
+		t.Fatalf("expected %+v, got %+v", expected, panel)
+	}
+	if len(panel.Layout.Panels.Panels) != len(expected.Layout.Panels.Panels) || panel.State.Brightness == nil {
+		t.Fatalf("expected the layout and state to be decoded, got %+v", panel)
+	}
+}
+
+// sortedKeys returns the API keys accepted by the controller in a stable order
+func sortedKeys(controller *nanoleaftest.Controller) []string {
+	keys := controller.APIKeys()
+	sort.Strings(keys)
+	return keys
+}
+
+func TestDeleteAPIKey(t *testing.T) {
+	controller := nanoleaftest.NewController()
+	defer controller.Close()
+	controller.SetPairing(true)
+	client := controller.Client()
+	ctx := context.Background()
+
+	key, err := client.CreateAPIKey(ctx)
+	if err != nil {
+		t.Fatalf("error creating key: %s", err)
+	}
+
+	// The specified key is deleted rather than the one used by the client
+	if err = client.DeleteAPIKey(ctx, key); err != nil {
+		t.Fatalf("error deleting key: %s", err)
+	}
+	if keys := sortedKeys(controller); !reflect.DeepEqual(keys, []string{nanoleaftest.DefaultAPIKey}) {
+		t.Fatalf("expected only the client key to remain, got %v", keys)
+	}
+	if _, err = client.GetPanel(ctx); err != nil {
+		t.Fatalf("expected the client key to still work, got %s", err)
+	}
+}
+
+func TestRotateAPIKey(t *testing.T) {
+	controller := nanoleaftest.NewController()
+	defer controller.Close()
+	controller.SetPairing(true)
+	client := controller.Client()
+	ctx := context.Background()
+
+	key, err := client.RotateAPIKey(ctx, nanoleaf.PairOptions{Interval: time.Millisecond})
+	if err != nil {
+		t.Fatalf("error rotating key: %s", err)
+	}
+
+	if key == nanoleaftest.DefaultAPIKey || client.APIKey() != key {
+		t.Fatalf("expected the client to use the new key %s, got %s", key, client.APIKey())
+	}
+	if keys := sortedKeys(controller); !reflect.DeepEqual(keys, []string{key}) {
+		t.Fatalf("expected only the new key to remain, got %v", keys)
+	}
+	if _, err = client.GetPanel(ctx); err != nil {
+		t.Fatalf("expected the new key to work, got %s", err)
+	}
+}
+
+func TestRotateAPIKeyVerificationFails(t *testing.T) {
+	controller := nanoleaftest.NewController()
+	defer controller.Close()
+	controller.SetPairing(true)
+	client := controller.Client(nanoleaf.WithRetryPolicy(nanoleaf.RetryPolicy{MaxAttempts: 1}))
+
+	// The new key is verified with a GET before the client switches to it
+	controller.Fail(http.MethodGet, "", http.StatusServiceUnavailable, 1)
+
+	if _, err := client.RotateAPIKey(context.Background(), nanoleaf.PairOptions{Interval: time.Millisecond}); !errors.Is(err, nanoleaf.ErrUnknown) {
+		t.Fatalf("expected the verification error, got %v", err)
+	}
+
+	if client.APIKey() != nanoleaftest.DefaultAPIKey {
+		t.Fatalf("expected the client to keep the old key, got %s", client.APIKey())
+	}
+	// The unverified key isn't left behind
+	if keys := sortedKeys(controller); !reflect.DeepEqual(keys, []string{nanoleaftest.DefaultAPIKey}) {
+		t.Fatalf("expected only the old key to remain, got %v", keys)
+	}
+}
+
+func TestRotateAPIKeyDeleteFails(t *testing.T) {
+	controller := nanoleaftest.NewController()
+	defer controller.Close()
+	controller.SetPairing(true)
+	client := controller.Client(nanoleaf.WithRetryPolicy(nanoleaf.RetryPolicy{MaxAttempts: 1}))
+
+	controller.Fail(http.MethodDelete, "", http.StatusServiceUnavailable, 1)
+
+	key, err := client.RotateAPIKey(context.Background(), nanoleaf.PairOptions{Interval: time.Millisecond})
+	if !errors.Is(err, nanoleaf.ErrUnknown) {
+		t.Fatalf("expected the delete error, got %v", err)
+	}
+
+	// The new key is returned and used as it has replaced the old one
+	if len(key) < 1 || client.APIKey() != key {
+		t.Fatalf("expected the client to use the new key %q, got %s", key, client.APIKey())
+	}
+	expected := []string{nanoleaftest.DefaultAPIKey, key}
+	sort.Strings(expected)
+	if keys := sortedKeys(controller); !reflect.DeepEqual(keys, expected) {
+		t.Fatalf("expected both keys to remain, got %v", keys)
+	}
+}
@@ -0,0 +1,254 @@
+package nanoleaf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidColor is returned if a colour string can't be parsed
+	ErrInvalidColor = errors.New("invalid color")
+)
+
+// RGBA converts the HSB value to RGB, allowing it to be used as a color.Color.
+// The probability is ignored.
+func (h HSB) RGBA() (r, g, b, a uint32) {
+	hue := math.Mod(float64(h.Hue), 360)
+	if hue < 0 {
+		hue += 360
+	}
+	sat := clamp(float64(h.Saturation)/100, 0, 1)
+	bri := clamp(float64(h.Brightness)/100, 0, 1)
+
+	chroma := bri * sat
+	x := chroma * (1 - math.Abs(math.Mod(hue/60, 2)-1))
+	m := bri - chroma
+
+	var rf, gf, bf float64
+	switch {
+	case hue < 60:
+		rf, gf, bf = chroma, x, 0
+	case hue < 120:
+		rf, gf, bf = x, chroma, 0
+	case hue < 180:
+		rf, gf, bf = 0, chroma, x
+	case hue < 240:
+		rf, gf, bf = 0, x, chroma
+	case hue < 300:
+		rf, gf, bf = x, 0, chroma
+	default:
+		rf, gf, bf = chroma, 0, x
+	}
+
+	return toChannel(rf + m), toChannel(gf + m), toChannel(bf + m), 0xffff
+}
+
+// HSBFromColor converts any colour into the hue/saturation/brightness ranges used by the panel
+func HSBFromColor(c color.Color) HSB {
+	if h, ok := c.(HSB); ok {
+		return h
+	}
+
+	rf, gf, bf := unitRGB(c)
+	max := math.Max(rf, math.Max(gf, bf))
+	min := math.Min(rf, math.Min(gf, bf))
+	delta := max - min
+
+	var hue float64
+	switch {
+	case delta == 0:
+		hue = 0
+	case max == rf:
+		hue = 60 * math.Mod((gf-bf)/delta, 6)
+	case max == gf:
+		hue = 60 * ((bf-rf)/delta + 2)
+	default:
+		hue = 60 * ((rf-gf)/delta + 4)
+	}
+	if hue < 0 {
+		hue += 360
+	}
+
+	var sat float64
+	if max > 0 {
+		sat = delta / max
+	}
+
+	return HSB{
+		Hue:        int(math.Round(hue)) % 360,
+		Saturation: int(math.Round(sat * 100)),
+		Brightness: int(math.Round(max * 100)),
+	}
+}
+
+// ParseHex parses a colour of the form "#RRGGBB" or "#RGB"; the leading '#' is optional
+func ParseHex(s string) (color.RGBA, error) {
+	hex := strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 {
+		return color.RGBA{}, fmt.Errorf("%w: %q", ErrInvalidColor, s)
+	}
+
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("%w: %q", ErrInvalidColor, s)
+	}
+
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}, nil
+}
+
+// Hex formats the colour as "#rrggbb"
+func Hex(c color.Color) string {
+	rgba := color.RGBAModel.Convert(c).(color.RGBA)
+	return fmt.Sprintf("#%02x%02x%02x", rgba.R, rgba.G, rgba.B)
+}
+
+// XYFromColor converts the colour to CIE 1931 xy chromaticity coordinates, assuming sRGB primaries with a D65 white point.
+// Black has no chromaticity, so the white point is returned for it.
+func XYFromColor(c color.Color) (x, y float64) {
+	rf, gf, bf := unitRGB(c)
+	rf, gf, bf = toLinear(rf), toLinear(gf), toLinear(bf)
+
+	X := rf*0.4124 + gf*0.3576 + bf*0.1805
+	Y := rf*0.2126 + gf*0.7152 + bf*0.0722
+	Z := rf*0.0193 + gf*0.1192 + bf*0.9505
+
+	sum := X + Y + Z
+	if sum == 0 {
+		return 0.3127, 0.3290
+	}
+	return X / sum, Y / sum
+}
+
+// ColorFromXY converts CIE 1931 xy chromaticity coordinates and a 0-1 brightness to an sRGB colour.
+// Chromaticities outside the sRGB gamut are clipped.
+func ColorFromXY(x, y, brightness float64) color.RGBA {
+	if y <= 0 {
+		return color.RGBA{A: 0xff}
+	}
+
+	Y := 1.0
+	X := x / y * Y
+	Z := (1 - x - y) / y * Y
+
+	rf := X*3.2406 - Y*1.5372 - Z*0.4986
+	gf := -X*0.9689 + Y*1.8758 + Z*0.0415
+	bf := X*0.0557 - Y*0.2040 + Z*1.0570
+
+	// Scale so the brightest channel matches the requested brightness
+	rf, gf, bf = math.Max(rf, 0), math.Max(gf, 0), math.Max(bf, 0)
+	if max := math.Max(rf, math.Max(gf, bf)); max > 0 {
+		rf, gf, bf = rf/max, gf/max, bf/max
+	}
+
+	bri := clamp(brightness, 0, 1)
+	return color.RGBA{
+		R: uint8(math.Round(fromLinear(rf) * bri * 255)),
+		G: uint8(math.Round(fromLinear(gf) * bri * 255)),
+		B: uint8(math.Round(fromLinear(bf) * bri * 255)),
+		A: 0xff,
+	}
+}
+
+// Kelvin is a colour temperature. It can be used as a color.Color, approximating the colour of a black body at that temperature.
+// The panels support 1200K to 6500K.
+type Kelvin int
+
+// RGBA approximates the colour of the temperature
+func (k Kelvin) RGBA() (r, g, b, a uint32) {
+	rf, gf, bf := blackBody(float64(k))
+	return toChannel(rf), toChannel(gf), toChannel(bf), 0xffff
+}
+
+// KelvinFromColor estimates the colour temperature, to the nearest 10K between 1000K and 40000K, whose approximated colour
+// (see Kelvin.RGBA) is closest to the colour once both are scaled to full brightness.
+// It is only meaningful for colours close to white; black has no temperature, so 6500K is returned for it.
+func KelvinFromColor(c color.Color) Kelvin {
+	if k, ok := c.(Kelvin); ok {
+		return k
+	}
+
+	rf, gf, bf := unitRGB(c)
+	max := math.Max(rf, math.Max(gf, bf))
+	if max == 0 {
+		return 6500
+	}
+	rf, gf, bf = rf/max, gf/max, bf/max
+
+	best, bestDistance := 1000, math.Inf(1)
+	for k := 1000; k <= 40000; k += 10 {
+		// The approximation always has a channel at full brightness
+		r, g, b := blackBody(float64(k))
+		if distance := (r-rf)*(r-rf) + (g-gf)*(g-gf) + (b-bf)*(b-bf); distance < bestDistance {
+			best, bestDistance = k, distance
+		}
+	}
+	return Kelvin(best)
+}
+
+// blackBody approximates the colour of a black body at the temperature as 0-1 channels
+func blackBody(kelvin float64) (float64, float64, float64) {
+	// Approximation by Tanner Helland of the black body spectrum, valid from 1000K to 40000K
+	t := clamp(kelvin, 1000, 40000) / 100
+
+	rf, gf, bf := 255.0, 0.0, 255.0
+	if t > 66 {
+		rf = 329.698727446 * math.Pow(t-60, -0.1332047592)
+		gf = 288.1221695283 * math.Pow(t-60, -0.0755148492)
+	} else {
+		gf = 99.4708025861*math.Log(t) - 161.1195681661
+		if t < 19 {
+			bf = 0
+		} else if t < 66 {
+			bf = 138.5177312231*math.Log(t-10) - 305.0447927307
+		}
+	}
+
+	return clamp(rf/255, 0, 1), clamp(gf/255, 0, 1), clamp(bf/255, 0, 1)
+}
+
+// SetColor sets the colour of the light. A Kelvin value sets the colour temperature;
+// any other colour is converted to hue, saturation and brightness which are applied together.
+func (c *Client) SetColor(ctx context.Context, col color.Color) error {
+	if k, ok := col.(Kelvin); ok {
+		return c.UpdateState(ctx, NewStateUpdate().CT(int(k)))
+	}
+
+	hsb := HSBFromColor(col)
+	return c.UpdateState(ctx, NewStateUpdate().Hue(hsb.Hue).Saturation(hsb.Saturation).Brightness(hsb.Brightness, 0))
+}
+
+// unitRGB returns the non-premultiplied channels of the colour as 0-1 values
+func unitRGB(c color.Color) (float64, float64, float64) {
+	nrgba := color.NRGBA64Model.Convert(c).(color.NRGBA64)
+	return float64(nrgba.R) / 0xffff, float64(nrgba.G) / 0xffff, float64(nrgba.B) / 0xffff
+}
+
+func toChannel(v float64) uint32 {
+	return uint32(math.Round(clamp(v, 0, 1) * 0xffff))
+}
+
+func toLinear(v float64) float64 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+func fromLinear(v float64) float64 {
+	if v <= 0.0031308 {
+		return v * 12.92
+	}
+	return 1.055*math.Pow(v, 1/2.4) - 0.055
+}
+
+func clamp(v float64, min float64, max float64) float64 {
+	return math.Max(min, math.Min(max, v))
+}
@@ -0,0 +1,187 @@
+package nanoleaf_test
+
+import (
+	"errors"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/rmrobinson/nanoleaf-go"
+)
+
+func TestHSBToRGB(t *testing.T) {
+	tests := []struct {
+		hsb      nanoleaf.HSB
+		expected string
+	}{
+		{nanoleaf.HSB{Hue: 0, Saturation: 100, Brightness: 100}, "#ff0000"},
+		{nanoleaf.HSB{Hue: 120, Saturation: 100, Brightness: 100}, "#00ff00"},
+		{nanoleaf.HSB{Hue: 240, Saturation: 100, Brightness: 50}, "#000080"},
+		{nanoleaf.HSB{Hue: 30, Saturation: 50, Brightness: 80}, "#cc9966"},
+		{nanoleaf.HSB{Hue: 0, Saturation: 0, Brightness: 100}, "#ffffff"},
+		{nanoleaf.HSB{Hue: 200, Saturation: 100, Brightness: 0}, "#000000"},
+		// Hues wrap around and out of range values are clamped
+		{nanoleaf.HSB{Hue: 480, Saturation: 150, Brightness: 100}, "#00ff00"},
+		{nanoleaf.HSB{Hue: -120, Saturation: 100, Brightness: 100}, "#0000ff"},
+	}
+
+	for _, test := range tests {
+		if actual := nanoleaf.Hex(test.hsb); actual != test.expected {
+			t.Errorf("expected %+v to be %s, got %s", test.hsb, test.expected, actual)
+		}
+	}
+}
+
+func TestHSBFromColor(t *testing.T) {
+	tests := []struct {
+		color    color.Color
+		expected nanoleaf.HSB
+	}{
+		{color.RGBA{R: 255, A: 255}, nanoleaf.HSB{Hue: 0, Saturation: 100, Brightness: 100}},
+		{color.RGBA{G: 255, A: 255}, nanoleaf.HSB{Hue: 120, Saturation: 100, Brightness: 100}},
+		{color.RGBA{B: 255, A: 255}, nanoleaf.HSB{Hue: 240, Saturation: 100, Brightness: 100}},
+		{color.RGBA{R: 255, G: 255, A: 255}, nanoleaf.HSB{Hue: 60, Saturation: 100, Brightness: 100}},
+		{color.RGBA{R: 255, B: 128, A: 255}, nanoleaf.HSB{Hue: 330, Saturation: 100, Brightness: 100}},
+		{color.RGBA{R: 128, G: 128, B: 128, A: 255}, nanoleaf.HSB{Hue: 0, Saturation: 0, Brightness: 50}},
+		{color.Black, nanoleaf.HSB{}},
+		{color.White, nanoleaf.HSB{Hue: 0, Saturation: 0, Brightness: 100}},
+		// Translucent colours are converted without the alpha
+		{color.NRGBA{R: 255, A: 128}, nanoleaf.HSB{Hue: 0, Saturation: 100, Brightness: 100}},
+		{nanoleaf.HSB{Hue: 10, Saturation: 20, Brightness: 30, Probability: 0.5}, nanoleaf.HSB{Hue: 10, Saturation: 20, Brightness: 30, Probability: 0.5}},
+	}
+
+	for _, test := range tests {
+		if actual := nanoleaf.HSBFromColor(test.color); actual != test.expected {
+			t.Errorf("expected %v to be %+v, got %+v", test.color, test.expected, actual)
+		}
+	}
+}
+
+func TestHSBRoundTrip(t *testing.T) {
+	for hue := 0; hue < 360; hue += 15 {
+		hsb := nanoleaf.HSB{Hue: hue, Saturation: 100, Brightness: 100}
+		if actual := nanoleaf.HSBFromColor(color.RGBAModel.Convert(hsb)); actual != hsb {
+			t.Errorf("expected %+v to round trip, got %+v", hsb, actual)
+		}
+	}
+}
+
+func TestParseHex(t *testing.T) {
+	tests := []struct {
+		hex      string
+		expected color.RGBA
+	}{
+		{"#ff8000", color.RGBA{R: 255, G: 128, A: 255}},
+		{"FF8000", color.RGBA{R: 255, G: 128, A: 255}},
+		{"#f80", color.RGBA{R: 255, G: 136, A: 255}},
+		{" #0a0B0c ", color.RGBA{R: 10, G: 11, B: 12, A: 255}},
+	}
+
+	for _, test := range tests {
+		actual, err := nanoleaf.ParseHex(test.hex)
+		if err != nil {
+			t.Errorf("error parsing %q: %s", test.hex, err)
+		} else if actual != test.expected {
+			t.Errorf("expected %q to be %v, got %v", test.hex, test.expected, actual)
+		}
+
+		if err == nil && nanoleaf.Hex(actual) != nanoleaf.Hex(test.expected) {
+			t.Errorf("expected %q to format as %s", test.hex, nanoleaf.Hex(test.expected))
+		}
+	}
+
+	for _, hex := range []string{"", "#", "#12345", "#1234567", "#gggggg", "#ff80", "red"} {
+		if _, err := nanoleaf.ParseHex(hex); !errors.Is(err, nanoleaf.ErrInvalidColor) {
+			t.Errorf("expected ErrInvalidColor for %q, got %v", hex, err)
+		}
+	}
+}
+
+func TestXY(t *testing.T) {
+	tests := []struct {
+		name  string
+		color color.RGBA
+		x, y  float64
+	}{
+		{"white", color.RGBA{R: 255, G: 255, B: 255, A: 255}, 0.3127, 0.3290},
+		{"red", color.RGBA{R: 255, A: 255}, 0.64, 0.33},
+		{"green", color.RGBA{G: 255, A: 255}, 0.30, 0.60},
+		{"blue", color.RGBA{B: 255, A: 255}, 0.15, 0.06},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			x, y := nanoleaf.XYFromColor(test.color)
+			if math.Abs(x-test.x) > 0.001 || math.Abs(y-test.y) > 0.001 {
+				t.Fatalf("expected (%g, %g), got (%g, %g)", test.x, test.y, x, y)
+			}
+
+			if actual := nanoleaf.ColorFromXY(x, y, 1); actual != test.color {
+				t.Fatalf("expected (%g, %g) to convert back to %v, got %v", x, y, test.color, actual)
+			}
+
+			half := nanoleaf.ColorFromXY(x, y, 0.5)
+			for _, channel := range [][2]uint8{{half.R, test.color.R}, {half.G, test.color.G}, {half.B, test.color.B}} {
+				if channel[1] > 0 && (channel[0] < 127 || channel[0] > 128) {
+					t.Fatalf("expected half brightness, got %v", half)
+				}
+			}
+		})
+	}
+
+	// Black has no chromaticity, so the white point is used
+	if x, y := nanoleaf.XYFromColor(color.Black); x != 0.3127 || y != 0.3290 {
+		t.Fatalf("expected the white point for black, got (%g, %g)", x, y)
+	}
+	if actual := nanoleaf.ColorFromXY(0.3, 0, 1); actual != (color.RGBA{A: 255}) {
+		t.Fatalf("expected black for y of 0, got %v", actual)
+	}
+}
+
+func TestKelvin(t *testing.T) {
+	tests := []struct {
+		kelvin   nanoleaf.Kelvin
+		expected string
+	}{
+		{1000, "#ff4400"},
+		{1500, "#ff6c00"},
+		{2700, "#ffa757"},
+		{4000, "#ffcea6"},
+		{6600, "#ffffff"},
+		{10000, "#cadaff"},
+		// Temperatures are clamped to the range of the approximation
+		{500, "#ff4400"},
+	}
+
+	for _, test := range tests {
+		if actual := nanoleaf.Hex(test.kelvin); actual != test.expected {
+			t.Errorf("expected %dK to be %s, got %s", test.kelvin, test.expected, actual)
+		}
+	}
+}
+
+func TestKelvinFromColor(t *testing.T) {
+	// Converting to 8 bit channels loses some precision
+	for _, k := range []nanoleaf.Kelvin{1000, 1200, 1500, 2700, 4000, 6500, 10000} {
+		actual := nanoleaf.KelvinFromColor(color.RGBAModel.Convert(k))
+		if math.Abs(float64(actual-k)) > float64(k)/100 {
+			t.Errorf("expected %dK to round trip, got %dK", k, actual)
+		}
+	}
+
+	tests := []struct {
+		color    color.Color
+		expected nanoleaf.Kelvin
+	}{
+		{nanoleaf.Kelvin(2700), 2700},
+		{color.Black, 6500},
+		// The brightness doesn't affect the temperature
+		{color.RGBA{R: 127, G: 127, B: 127, A: 255}, 6600},
+		{color.White, 6600},
+	}
+	for _, test := range tests {
+		if actual := nanoleaf.KelvinFromColor(test.color); actual != test.expected {
+			t.Errorf("expected %v to be %dK, got %dK", test.color, test.expected, actual)
+		}
+	}
+}
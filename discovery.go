@@ -0,0 +1,322 @@
+package nanoleaf
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultDiscoveryTimeout = 3 * time.Second
+	defaultSSDPAddress      = "239.255.255.250:1900"
+	defaultMDNSAddress      = "224.0.0.251:5353"
+	defaultPort             = 16021
+
+	mdnsService = "_nanoleafapi._tcp.local."
+)
+
+// DefaultSearchTargets are the SSDP search targets used to find the different Nanoleaf products
+var DefaultSearchTargets = []string{
+	"nanoleaf_aurora:light",
+	"nanoleaf:nl29",
+	"nanoleaf:nl42",
+	"nanoleaf:nl47",
+	"nanoleaf:nl52",
+	"nanoleaf:nl59",
+}
+
+// DiscoveredDevice contains the details of a panel controller found on the network.
+// The Host and Port can be supplied to NewClient.
+type DiscoveredDevice struct {
+	Name     string
+	Host     string
+	Port     int
+	Model    string
+	DeviceID string
+}
+
+// Discoverer searches the local network for panel controllers using both SSDP and mDNS.
+// The zero value is ready to use.
+type Discoverer struct {
+	// Timeout bounds the search if the context has no deadline (defaults to 3 seconds)
+	Timeout time.Duration
+	// SSDPAddress is where SSDP searches are sent (defaults to the SSDP multicast group)
+	SSDPAddress string
+	// MDNSAddress is where mDNS queries are sent (defaults to the mDNS multicast group)
+	MDNSAddress string
+	// SearchTargets are the SSDP search targets (defaults to DefaultSearchTargets)
+	SearchTargets []string
+}
+
+// Discover searches the local network for panel controllers using the default Discoverer
+func Discover(ctx context.Context) ([]DiscoveredDevice, error) {
+	return (&Discoverer{}).Discover(ctx)
+}
+
+// Discover searches the local network for panel controllers until the context deadline (or timeout) passes.
+// Devices which respond to both protocols or respond multiple times are only returned once.
+func (d *Discoverer) Discover(ctx context.Context) ([]DiscoveredDevice, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		timeout := d.Timeout
+		if timeout <= 0 {
+			timeout = defaultDiscoveryTimeout
+		}
+
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	ssdpAddr := d.SSDPAddress
+	if len(ssdpAddr) < 1 {
+		ssdpAddr = defaultSSDPAddress
+	}
+	mdnsAddr := d.MDNSAddress
+	if len(mdnsAddr) < 1 {
+		mdnsAddr = defaultMDNSAddress
+	}
+	targets := d.SearchTargets
+	if len(targets) < 1 {
+		targets = DefaultSearchTargets
+	}
+
+	var (
+		wg       sync.WaitGroup
+		lock     sync.Mutex
+		devices  []DiscoveredDevice
+		ssdpErr  error
+		mdnsErr  error
+		addFound = func(found DiscoveredDevice) {
+			lock.Lock()
+			defer lock.Unlock()
+			devices = mergeDevice(devices, found)
+		}
+	)
+
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		ssdpErr = searchSSDP(ctx, ssdpAddr, targets, addFound)
+	}()
+	go func() {
+		defer wg.Done()
+		mdnsErr = searchMDNS(ctx, mdnsAddr, addFound)
+	}()
+	wg.Wait()
+
+	if ssdpErr != nil && mdnsErr != nil {
+		return nil, ssdpErr
+	}
+	return devices, nil
+}
+
+// mergeDevice adds the found device to the list, filling in any missing details if the device is already present
+func mergeDevice(devices []DiscoveredDevice, found DiscoveredDevice) []DiscoveredDevice {
+	for i := range devices {
+		existing := &devices[i]
+
+		sameID := len(found.DeviceID) > 0 && strings.EqualFold(existing.DeviceID, found.DeviceID)
+		sameAddr := existing.Host == found.Host && existing.Port == found.Port
+		if !sameID && !sameAddr {
+			continue
+		}
+
+		if len(existing.Name) < 1 {
+			existing.Name = found.Name
+		}
+		if len(existing.Model) < 1 {
+			existing.Model = found.Model
+		}
+		if len(existing.DeviceID) < 1 {
+			existing.DeviceID = found.DeviceID
+		}
+		return devices
+	}
+
+	return append(devices, found)
+}
+
+// listen opens a socket to send queries from and closes it once the context is done
+func listen(ctx context.Context) (net.PacketConn, error) {
+	conn, err := net.ListenPacket("udp4", ":0")
+	if err != nil {
+		return nil, err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	return conn, nil
+}
+
+func searchSSDP(ctx context.Context, addr string, targets []string, found func(DiscoveredDevice)) error {
+	dst, err := net.ResolveUDPAddr("udp4", addr)
+	if err != nil {
+		return err
+	}
+
+	conn, err := listen(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	for _, target := range targets {
+		msg := "M-SEARCH * HTTP/1.1\r\n" +
+			"HOST: " + addr + "\r\n" +
+			"MAN: \"ssdp:discover\"\r\n" +
+			"MX: 1\r\n" +
+			"ST: " + target + "\r\n\r\n"
+
+		if _, err = conn.WriteTo([]byte(msg), dst); err != nil {
+			return err
+		}
+	}
+
+	buf := make([]byte, 2048)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			// The socket is closed (or times out) once the search is complete
+			return nil
+		}
+
+		if device, ok := parseSSDPResponse(buf[:n]); ok {
+			found(device)
+		}
+	}
+}
+
+func parseSSDPResponse(b []byte) (DiscoveredDevice, bool) {
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(b)), nil)
+	if err != nil {
+		return DiscoveredDevice{}, false
+	}
+	resp.Body.Close()
+
+	target := strings.ToLower(resp.Header.Get("ST"))
+	if !strings.HasPrefix(target, "nanoleaf") {
+		return DiscoveredDevice{}, false
+	}
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil || len(location.Hostname()) < 1 {
+		return DiscoveredDevice{}, false
+	}
+
+	device := DiscoveredDevice{
+		Name:     resp.Header.Get("nl-devicename"),
+		Host:     location.Hostname(),
+		Port:     defaultPort,
+		DeviceID: resp.Header.Get("nl-deviceid"),
+	}
+	if port, err := strconv.Atoi(location.Port()); err == nil {
+		device.Port = port
+	}
+
+	// The search target identifies the model, e.g. nanoleaf:nl29 for the Canvas
+	if target == "nanoleaf_aurora:light" {
+		device.Model = "NL22"
+	} else if idx := strings.LastIndex(target, ":"); idx >= 0 {
+		device.Model = strings.ToUpper(target[idx+1:])
+	}
+
+	return device, true
+}
+
+func searchMDNS(ctx context.Context, addr string, found func(DiscoveredDevice)) error {
+	dst, err := net.ResolveUDPAddr("udp4", addr)
+	if err != nil {
+		return err
+	}
+
+	conn, err := listen(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// Queries from a port other than 5353 are answered directly to the sender
+	if _, err = conn.WriteTo(newMDNSQuery(mdnsService), dst); err != nil {
+		return err
+	}
+
+	buf := make([]byte, 9000)
+	for {
+		n, from, err := conn.ReadFrom(buf)
+		if err != nil {
+			return nil
+		}
+
+		records, err := parseMDNSResponse(buf[:n])
+		if err != nil {
+			continue
+		}
+
+		var fromHost string
+		if udpAddr, ok := from.(*net.UDPAddr); ok {
+			fromHost = udpAddr.IP.String()
+		}
+
+		for _, device := range devicesFromRecords(records, fromHost) {
+			found(device)
+		}
+	}
+}
+
+// devicesFromRecords assembles the devices advertised in a set of mDNS records.
+// The packet source address is used if the response doesn't include an address record.
+func devicesFromRecords(records []dnsRecord, fromHost string) []DiscoveredDevice {
+	addrs := map[string]string{}
+	txts := map[string][]string{}
+	for _, r := range records {
+		switch r.Type {
+		case dnsTypeA, dnsTypeAAAA:
+			if _, ok := addrs[r.Name]; !ok || r.Type == dnsTypeA {
+				addrs[r.Name] = r.IP.String()
+			}
+		case dnsTypeTXT:
+			txts[r.Name] = r.Text
+		}
+	}
+
+	var devices []DiscoveredDevice
+	for _, r := range records {
+		if r.Type != dnsTypeSRV || !strings.HasSuffix(strings.ToLower(r.Name), mdnsService) {
+			continue
+		}
+
+		device := DiscoveredDevice{
+			Name: strings.TrimSuffix(r.Name, "."+mdnsService),
+			Host: fromHost,
+			Port: r.Port,
+		}
+		if host, ok := addrs[r.Target]; ok {
+			device.Host = host
+		}
+		for _, kv := range txts[r.Name] {
+			if strings.HasPrefix(kv, "id=") {
+				device.DeviceID = kv[3:]
+			} else if strings.HasPrefix(kv, "md=") {
+				device.Model = kv[3:]
+			}
+		}
+
+		if len(device.Host) > 0 {
+			devices = append(devices, device)
+		}
+	}
+	return devices
+}
@@ -0,0 +1,374 @@
+package nanoleaf
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// startResponder answers each packet received on a local socket with the responses returned by respond
+func startResponder(t *testing.T, respond func(query []byte) [][]byte) string {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening: %s", err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+	})
+
+	go func() {
+		buf := make([]byte, 9000)
+		for {
+			n, from, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+
+			for _, resp := range respond(append([]byte(nil), buf[:n]...)) {
+				conn.WriteTo(resp, from)
+			}
+		}
+	}()
+
+	return conn.LocalAddr().String()
+}
+
+func ssdpResponder(query []byte) [][]byte {
+	msg := string(query)
+	if !strings.HasPrefix(msg, "M-SEARCH") {
+		return nil
+	}
+
+	switch {
+	case strings.Contains(msg, "ST: nanoleaf:nl42\r\n"):
+		resp := []byte("HTTP/1.1 200 OK\r\n" +
+			"CACHE-CONTROL: max-age=60\r\n" +
+			"ST: nanoleaf:nl42\r\n" +
+			"Location: http://192.168.1.50:16021\r\n" +
+			"nl-deviceid: AA:BB:CC:DD:EE:01\r\n" +
+			"nl-devicename: Shapes 0A1B\r\n\r\n")
+		// Devices commonly answer more than once
+		return [][]byte{resp, resp}
+	case strings.Contains(msg, "ST: nanoleaf_aurora:light\r\n"):
+		return [][]byte{[]byte("HTTP/1.1 200 OK\r\n" +
+			"ST: nanoleaf_aurora:light\r\n" +
+			"Location: http://192.168.1.51:16021\r\n" +
+			"nl-deviceid: AA:BB:CC:DD:EE:02\r\n" +
+			"nl-devicename: Light Panels 2C3D\r\n\r\n")}
+	case strings.Contains(msg, "ST: nanoleaf:nl29\r\n"):
+		// Not a Nanoleaf device
+		return [][]byte{[]byte("HTTP/1.1 200 OK\r\nST: upnp:rootdevice\r\nLocation: http://192.168.1.99/\r\n\r\n")}
+	}
+	return nil
+}
+
+// dnsLabels encodes the labels of a name, optionally ending with a compression pointer instead of the root label
+func dnsLabels(pointer int, labels ...string) []byte {
+	var b []byte
+	for _, label := range labels {
+		b = append(b, byte(len(label)))
+		b = append(b, label...)
+	}
+	if pointer >= 0 {
+		return binary.BigEndian.AppendUint16(b, 0xC000|uint16(pointer))
+	}
+	return append(b, 0)
+}
+
+func dnsResourceRecord(name []byte, recordType uint16, data []byte) []byte {
+	b := append([]byte(nil), name...)
+	b = binary.BigEndian.AppendUint16(b, recordType)
+	b = binary.BigEndian.AppendUint16(b, dnsClassIN)
+	b = binary.BigEndian.AppendUint32(b, 120)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(data)))
+	return append(b, data...)
+}
+
+// mdnsResponse builds the response a Shapes controller sends when browsed, using name compression
+func mdnsResponse() []byte {
+	b := []byte{0, 0, 0x84, 0, 0, 1, 0, 1, 0, 0, 0, 3}
+
+	// The question starts at offset 12 and is referred to by the records
+	const service = 12
+	b = append(b, dnsLabels(-1, "_nanoleafapi", "_tcp", "local")...)
+	b = binary.BigEndian.AppendUint16(b, dnsTypePTR)
+	b = binary.BigEndian.AppendUint16(b, dnsClassIN)
+
+	instance := dnsLabels(service, "Shapes 0A1B")
+	host := dnsLabels(-1, "Shapes-0A1B", "local")
+
+	b = append(b, dnsResourceRecord(dnsLabels(service), dnsTypePTR, instance)...)
+
+	srv := []byte{0, 0, 0, 0}
+	srv = binary.BigEndian.AppendUint16(srv, 16021)
+	b = append(b, dnsResourceRecord(instance, dnsTypeSRV, append(srv, host...))...)
+
+	var txt []byte
+	for _, kv := range []string{"srcvers=5.1.0", "md=NL42", "id=AA:BB:CC:DD:EE:01"} {
+		txt = append(txt, byte(len(kv)))
+		txt = append(txt, kv...)
+	}
+	b = append(b, dnsResourceRecord(instance, dnsTypeTXT, txt)...)
+	b = append(b, dnsResourceRecord(host, dnsTypeA, []byte{192, 168, 1, 50})...)
+	return b
+}
+
+func mdnsResponder(query []byte) [][]byte {
+	if len(query) < 12 || query[2]&0x80 != 0 {
+		return nil
+	}
+	return [][]byte{mdnsResponse()}
+}
+
+func TestDiscover(t *testing.T) {
+	d := &Discoverer{
+		Timeout:     500 * time.Millisecond,
+		SSDPAddress: startResponder(t, ssdpResponder),
+		MDNSAddress: startResponder(t, mdnsResponder),
+	}
+
+	devices, err := d.Discover(context.Background())
+	if err != nil {
+		t.Fatalf("error discovering: %s", err)
+	}
+
+	sort.Slice(devices, func(i, j int) bool {
+		return devices[i].Host < devices[j].Host
+	})
+	expected := []DiscoveredDevice{
+		{Name: "Shapes 0A1B", Host: "192.168.1.50", Port: 16021, Model: "NL42", DeviceID: "AA:BB:CC:DD:EE:01"},
+		{Name: "Light Panels 2C3D", Host: "192.168.1.51", Port: 16021, Model: "NL22", DeviceID: "AA:BB:CC:DD:EE:02"},
+	}
+	if !reflect.DeepEqual(devices, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, devices)
+	}
+}
+
+func TestDiscoverMDNSOnly(t *testing.T) {
+	d := &Discoverer{
+		Timeout:       500 * time.Millisecond,
+		SSDPAddress:   startResponder(t, ssdpResponder),
+		MDNSAddress:   startResponder(t, mdnsResponder),
+		SearchTargets: []string{"nanoleaf:nl99"},
+	}
+
+	devices, err := d.Discover(context.Background())
+	if err != nil {
+		t.Fatalf("error discovering: %s", err)
+	}
+
+	expected := []DiscoveredDevice{
+		{Name: "Shapes 0A1B", Host: "192.168.1.50", Port: 16021, Model: "NL42", DeviceID: "AA:BB:CC:DD:EE:01"},
+	}
+	if !reflect.DeepEqual(devices, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, devices)
+	}
+}
+
+func TestParseMDNSResponse(t *testing.T) {
+	records, err := parseMDNSResponse(mdnsResponse())
+	if err != nil {
+		t.Fatalf("error parsing: %s", err)
+	}
+
+	expected := []dnsRecord{
+		{Name: "_nanoleafapi._tcp.local.", Type: dnsTypePTR, Target: "Shapes 0A1B._nanoleafapi._tcp.local."},
+		{Name: "Shapes 0A1B._nanoleafapi._tcp.local.", Type: dnsTypeSRV, Target: "Shapes-0A1B.local.", Port: 16021},
+		{Name: "Shapes 0A1B._nanoleafapi._tcp.local.", Type: dnsTypeTXT, Text: []string{"srcvers=5.1.0", "md=NL42", "id=AA:BB:CC:DD:EE:01"}},
+		{Name: "Shapes-0A1B.local.", Type: dnsTypeA, IP: net.IP{192, 168, 1, 50}},
+	}
+	if !reflect.DeepEqual(records, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, records)
+	}
+}
+
+func TestParseMDNSResponseMalformed(t *testing.T) {
+	valid := mdnsResponse()
+
+	query := append([]byte(nil), valid...)
+	query[2] = 0
+
+	tooManyRecords := append([]byte(nil), valid...)
+	tooManyRecords[11] = 4
+
+	// A response with no questions and a single record
+	singleRecord := func(record []byte) []byte {
+		return append([]byte{0, 0, 0x84, 0, 0, 0, 0, 1, 0, 0, 0, 0}, record...)
+	}
+
+	tests := []struct {
+		name string
+		msg  []byte
+	}{
+		{"empty", nil},
+		{"short header", valid[:11]},
+		{"query", query},
+		{"truncated question", valid[:20]},
+		{"truncated record", valid[:len(valid)-2]},
+		{"missing record", tooManyRecords},
+		{"short srv", singleRecord(dnsResourceRecord(dnsLabels(-1, "a"), dnsTypeSRV, []byte{0, 0, 0}))},
+		{"bad txt length", singleRecord(dnsResourceRecord(dnsLabels(-1, "a"), dnsTypeTXT, []byte{5, 'a'}))},
+		{"looping ptr target", singleRecord(dnsResourceRecord(dnsLabels(-1, "a"), dnsTypePTR, []byte{0xC0, 25}))},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := parseMDNSResponse(test.msg); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+func TestReadDNSName(t *testing.T) {
+	tests := []struct {
+		name     string
+		msg      []byte
+		off      int
+		expected string
+		next     int
+		valid    bool
+	}{
+		{
+			name:     "labels",
+			msg:      dnsLabels(-1, "nanoleaf", "local"),
+			expected: "nanoleaf.local.",
+			next:     16,
+			valid:    true,
+		},
+		{
+			name:     "root",
+			msg:      []byte{0},
+			expected: ".",
+			next:     1,
+			valid:    true,
+		},
+		{
+			name:     "pointer",
+			msg:      append(dnsLabels(-1, "local"), dnsLabels(0, "nanoleaf")...),
+			off:      7,
+			expected: "nanoleaf.local.",
+			next:     18,
+			valid:    true,
+		},
+		{
+			name:     "chained pointers",
+			msg:      append(append(dnsLabels(-1, "local"), dnsLabels(0, "_tcp")...), dnsLabels(7, "_nanoleafapi")...),
+			off:      14,
+			expected: "_nanoleafapi._tcp.local.",
+			next:     29,
+			valid:    true,
+		},
+		{
+			name: "pointer to itself",
+			msg:  []byte{0xC0, 0x00},
+		},
+		{
+			name: "pointers to each other",
+			msg:  []byte{0xC0, 0x02, 0xC0, 0x00},
+		},
+		{
+			name: "label looping through pointer",
+			msg:  append(dnsLabels(-1, "a")[:2], 0xC0, 0x00),
+		},
+		{
+			name: "pointer past end",
+			msg:  []byte{0xC0, 0x10},
+		},
+		{
+			name: "truncated pointer",
+			msg:  []byte{1, 'a', 0xC0},
+		},
+		{
+			name: "truncated label",
+			msg:  []byte{5, 'a', 'b'},
+		},
+		{
+			name: "missing terminator",
+			msg:  []byte{1, 'a'},
+		},
+		{
+			name: "reserved label type",
+			msg:  []byte{0x80, 'a', 0},
+		},
+		{
+			name: "offset past end",
+			msg:  []byte{0},
+			off:  4,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			name, next, err := readDNSName(test.msg, test.off)
+			if !test.valid {
+				if err == nil {
+					t.Fatalf("expected an error, got %q", name)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("error reading name: %s", err)
+			} else if name != test.expected || next != test.next {
+				t.Fatalf("expected %q ending at %d, got %q ending at %d", test.expected, test.next, name, next)
+			}
+		})
+	}
+}
+
+func TestMergeDevice(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing []DiscoveredDevice
+		found    DiscoveredDevice
+		expected []DiscoveredDevice
+	}{
+		{
+			name:     "new device",
+			existing: []DiscoveredDevice{{Host: "10.0.0.1", Port: 16021, DeviceID: "A"}},
+			found:    DiscoveredDevice{Host: "10.0.0.2", Port: 16021, DeviceID: "B"},
+			expected: []DiscoveredDevice{{Host: "10.0.0.1", Port: 16021, DeviceID: "A"}, {Host: "10.0.0.2", Port: 16021, DeviceID: "B"}},
+		},
+		{
+			name:     "same ID ignoring case",
+			existing: []DiscoveredDevice{{Host: "10.0.0.1", Port: 16021, DeviceID: "aa:bb"}},
+			found:    DiscoveredDevice{Name: "Canvas", Host: "fe80::1", Port: 16021, DeviceID: "AA:BB"},
+			expected: []DiscoveredDevice{{Name: "Canvas", Host: "10.0.0.1", Port: 16021, DeviceID: "aa:bb"}},
+		},
+		{
+			name:     "same address fills in details",
+			existing: []DiscoveredDevice{{Host: "10.0.0.1", Port: 16021, Model: "NL29"}},
+			found:    DiscoveredDevice{Name: "Canvas", Host: "10.0.0.1", Port: 16021, Model: "NL42", DeviceID: "A"},
+			expected: []DiscoveredDevice{{Name: "Canvas", Host: "10.0.0.1", Port: 16021, Model: "NL29", DeviceID: "A"}},
+		},
+		{
+			name:     "same host different port",
+			existing: []DiscoveredDevice{{Host: "10.0.0.1", Port: 16021}},
+			found:    DiscoveredDevice{Host: "10.0.0.1", Port: 16022},
+			expected: []DiscoveredDevice{{Host: "10.0.0.1", Port: 16021}, {Host: "10.0.0.1", Port: 16022}},
+		},
+		{
+			name:     "missing IDs don't match",
+			existing: []DiscoveredDevice{{Host: "10.0.0.1", Port: 16021}},
+			found:    DiscoveredDevice{Host: "10.0.0.2", Port: 16021},
+			expected: []DiscoveredDevice{{Host: "10.0.0.1", Port: 16021}, {Host: "10.0.0.2", Port: 16021}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			devices := mergeDevice(test.existing, test.found)
+			if !reflect.DeepEqual(devices, test.expected) {
+				t.Fatalf("expected %+v, got %+v", test.expected, devices)
+			}
+		})
+	}
+}
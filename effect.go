@@ -1,6 +1,9 @@
 package nanoleaf
 
-import "context"
+import (
+	"context"
+	"encoding/json"
+)
 
 const (
 	// WheelPluginUUID is the UUID of the wheel plugin
@@ -59,23 +62,138 @@ func (c *Client) GetEffects(ctx context.Context) ([]Effect, error) {
 	return resp.Effects, nil
 }
 
+// AddEffect installs the specified effect on the panel, replacing any existing effect with the same name
+func (c *Client) AddEffect(ctx context.Context, effect *Effect) error {
+	return c.writeEffect(ctx, effectCommand{
+		Command: "add",
+		Effect:  effect,
+	})
+}
+
+// UpdateEffect replaces an existing effect with the specified one. ErrNotFound is returned if no effect with the same name exists.
+func (c *Client) UpdateEffect(ctx context.Context, effect *Effect) error {
+	if _, err := c.GetEffect(ctx, effect.Name); err != nil {
+		return err
+	}
+
+	return c.AddEffect(ctx, effect)
+}
+
+// RenameEffect changes the name of the specified effect
+func (c *Client) RenameEffect(ctx context.Context, effectName string, newName string) error {
+	return c.writeEffect(ctx, effectCommand{
+		Command: "rename",
+		NewName: newName,
+		Effect:  &Effect{Name: effectName},
+	})
+}
+
+// DeleteEffect removes the specified effect from the panel
+func (c *Client) DeleteEffect(ctx context.Context, effectName string) error {
+	return c.writeEffect(ctx, effectCommand{
+		Command: "delete",
+		Effect:  &Effect{Name: effectName},
+	})
+}
+
+// DisplayEffect previews the specified effect on the panel without installing it.
+// If duration (in seconds) is set the panel reverts to the previous effect once it elapses.
+func (c *Client) DisplayEffect(ctx context.Context, effect *Effect, duration int) error {
+	cmd := effectCommand{
+		Command: "display",
+		Effect:  effect,
+	}
+	if duration > 0 {
+		cmd.Command = "displayTemp"
+		cmd.Duration = duration
+	}
+
+	return c.writeEffect(ctx, cmd)
+}
+
+// effectCommand is the body of a write command; the effect fields are serialized alongside the command fields
+type effectCommand struct {
+	Command  string
+	Duration int
+	NewName  string
+	*Effect
+}
+
+// MarshalJSON serializes the command and effect fields together.
+// Ranges which aren't set (see MaxMin) are left out, as they don't apply to every type of effect.
+func (cmd effectCommand) MarshalJSON() ([]byte, error) {
+	fields := map[string]json.RawMessage{}
+	if cmd.Effect != nil {
+		b, err := json.Marshal(cmd.Effect)
+		if err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal(b, &fields); err != nil {
+			return nil, err
+		}
+
+		for name, value := range map[string]MaxMin{
+			"brightnessRange": cmd.BrightnessRange,
+			"transTime":       cmd.TransitionTime,
+			"delayTime":       cmd.DelayTime,
+		} {
+			if !value.isSet() {
+				delete(fields, name)
+			}
+		}
+	}
+
+	fields["command"], _ = json.Marshal(cmd.Command)
+	if cmd.Duration > 0 {
+		fields["duration"], _ = json.Marshal(cmd.Duration)
+	}
+	if len(cmd.NewName) > 0 {
+		fields["newName"], _ = json.Marshal(cmd.NewName)
+	}
+	return json.Marshal(fields)
+}
+
+func (c *Client) writeEffect(ctx context.Context, cmd effectCommand) error {
+	var req struct {
+		Body effectCommand `json:"write"`
+	}
+
+	req.Body = cmd
+
+	// Once applied, renaming or deleting again fails as the effect no longer exists, so these must not be blindly retried
+	if cmd.Command == "rename" || cmd.Command == "delete" {
+		return c.putNonIdempotent(ctx, "effects", req, nil)
+	}
+	return c.put(ctx, "effects", req, nil)
+}
+
 // Effect represents a single effect in the panel
 type Effect struct {
-	Name            string `json:"animName"`
-	Version         string `json:"version"`
-	PluginType      string `json:"pluginType"`
-	PluginUUID      string `json:"pluginUuid"`
-	Palette         []HSB  `json:"palette"`
+	Name          string         `json:"animName"`
+	Version       string         `json:"version,omitempty"`
+	PluginType    string         `json:"pluginType,omitempty"`
+	PluginUUID    string         `json:"pluginUuid,omitempty"`
+	PluginOptions []PluginOption `json:"pluginOptions,omitempty"`
+	Palette       []HSB          `json:"palette,omitempty"`
+	// AnimationData contains the per-panel frames of custom and static effects
+	AnimationData   string `json:"animData,omitempty"`
 	BrightnessRange MaxMin `json:"brightnessRange"`
 	TransitionTime  MaxMin `json:"transTime"`
 	DelayTime       MaxMin `json:"delayTime"`
-	ColorType       string `json:"colorType"`
-	AnimationType   string `json:"animType"`
-	FlowFactor      int    `json:"flowFactor"`
-	ExplodeFactor   int    `json:"explodeFactor"`
-	WindowSize      int    `json:"windowSize"`
-	Direction       string `json:"direction"`
-	Loop            bool   `json:"loop"`
+	ColorType       string `json:"colorType,omitempty"`
+	AnimationType   string `json:"animType,omitempty"`
+	FlowFactor      int    `json:"flowFactor,omitempty"`
+	ExplodeFactor   int    `json:"explodeFactor,omitempty"`
+	WindowSize      int    `json:"windowSize,omitempty"`
+	Direction       string `json:"direction,omitempty"`
+	// Loop is left out if nil, as it only applies to some types of effect
+	Loop *bool `json:"loop,omitempty"`
+}
+
+// PluginOption represents a single setting passed to the plugin of an effect
+type PluginOption struct {
+	Name  string      `json:"name"`
+	Value interface{} `json:"value"`
 }
 
 // HSB represents a hue/saturation/brightness entry
@@ -87,11 +205,33 @@ type HSB struct {
 	// Brightness is a 0-100 value
 	Brightness int `json:"brightness"`
 	// Probability reflects the chance the above HSB value will apply
-	Probability float64 `json:"probability"`
+	Probability float64 `json:"probability,omitempty"`
 }
 
-// MaxMin represents a pair of values for max and min
+// MaxMin represents a pair of values for max and min.
+// A range is only sent to the panel if it is set: either one of the values is non-zero,
+// Set is true, or the range was received from the panel.
 type MaxMin struct {
 	Maximum int `json:"maxValue"`
 	Minimum int `json:"minValue"`
+	// Set marks the range as specified, allowing a range of zeros to be sent
+	Set bool `json:"-"`
+}
+
+// UnmarshalJSON decodes the range, marking it as set
+func (mm *MaxMin) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		return nil
+	}
+
+	type maxMin MaxMin
+	if err := json.Unmarshal(b, (*maxMin)(mm)); err != nil {
+		return err
+	}
+	mm.Set = true
+	return nil
+}
+
+func (mm MaxMin) isSet() bool {
+	return mm.Set || mm.Maximum != 0 || mm.Minimum != 0
 }
@@ -0,0 +1,142 @@
+package nanoleaf
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestEffectCommandMarshalJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		cmd      effectCommand
+		expected map[string]interface{}
+	}{
+		{
+			name: "unset ranges are omitted",
+			cmd: effectCommand{
+				Command: "add",
+				Effect: &Effect{
+					Name:          "Static",
+					AnimationType: "static",
+					AnimationData: "1 5 1 255 0 0 0 1",
+				},
+			},
+			expected: map[string]interface{}{
+				"command":  "add",
+				"animName": "Static",
+				"animType": "static",
+				"animData": "1 5 1 255 0 0 0 1",
+			},
+		},
+		{
+			name: "set ranges are included",
+			cmd: effectCommand{
+				Command:  "displayTemp",
+				Duration: 10,
+				Effect: &Effect{
+					Name:           "Flow",
+					AnimationType:  "flow",
+					TransitionTime: MaxMin{Maximum: 30, Minimum: 10},
+				},
+			},
+			expected: map[string]interface{}{
+				"command":   "displayTemp",
+				"duration":  float64(10),
+				"animName":  "Flow",
+				"animType":  "flow",
+				"transTime": map[string]interface{}{"maxValue": float64(30), "minValue": float64(10)},
+			},
+		},
+		{
+			name: "explicitly set zero ranges and loop are included",
+			cmd: effectCommand{
+				Command: "add",
+				Effect: &Effect{
+					Name:          "Wheel",
+					AnimationType: "plugin",
+					DelayTime:     MaxMin{Set: true},
+					Loop:          new(bool),
+				},
+			},
+			expected: map[string]interface{}{
+				"command":   "add",
+				"animName":  "Wheel",
+				"animType":  "plugin",
+				"delayTime": map[string]interface{}{"maxValue": float64(0), "minValue": float64(0)},
+				"loop":      false,
+			},
+		},
+		{
+			name: "rename",
+			cmd: effectCommand{
+				Command: "rename",
+				NewName: "New",
+				Effect:  &Effect{Name: "Old"},
+			},
+			expected: map[string]interface{}{
+				"command":  "rename",
+				"animName": "Old",
+				"newName":  "New",
+			},
+		},
+		{
+			name: "no effect",
+			cmd: effectCommand{
+				Command: "requestAll",
+			},
+			expected: map[string]interface{}{
+				"command": "requestAll",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b, err := json.Marshal(test.cmd)
+			if err != nil {
+				t.Fatalf("error marshalling: %s", err)
+			}
+
+			var actual map[string]interface{}
+			if err = json.Unmarshal(b, &actual); err != nil {
+				t.Fatalf("error unmarshalling %s: %s", b, err)
+			}
+			if !reflect.DeepEqual(actual, test.expected) {
+				t.Fatalf("expected %v, got %v", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestEffectRangesRoundTrip(t *testing.T) {
+	var effect Effect
+	err := json.Unmarshal([]byte(`{"animName":"Wheel","delayTime":{"maxValue":0,"minValue":0},"transTime":{"maxValue":20,"minValue":5},"loop":false}`), &effect)
+	if err != nil {
+		t.Fatalf("error unmarshalling: %s", err)
+	}
+	if !effect.DelayTime.Set || !effect.TransitionTime.Set || effect.BrightnessRange.Set {
+		t.Fatalf("expected only the received ranges to be set, got %+v", effect)
+	}
+
+	// Ranges received from the panel are sent back even if they are zero
+	b, err := json.Marshal(effectCommand{Command: "add", Effect: &effect})
+	if err != nil {
+		t.Fatalf("error marshalling: %s", err)
+	}
+
+	var actual map[string]interface{}
+	if err = json.Unmarshal(b, &actual); err != nil {
+		t.Fatalf("error unmarshalling %s: %s", b, err)
+	}
+	expected := map[string]interface{}{
+		"command":   "add",
+		"animName":  "Wheel",
+		"delayTime": map[string]interface{}{"maxValue": float64(0), "minValue": float64(0)},
+		"transTime": map[string]interface{}{"maxValue": float64(20), "minValue": float64(5)},
+		"loop":      false,
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+}
@@ -0,0 +1,115 @@
+package nanoleaf
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// maxErrorBodyLength is the amount of the response body retained in an APIError
+const maxErrorBodyLength = 512
+
+// APIError contains the details of a request which was rejected by the panel.
+// It wraps one of the package errors (such as ErrUnauthorized) so it can be checked with errors.Is.
+type APIError struct {
+	Method string
+	// Path is the request path, with any API key redacted
+	Path       string
+	StatusCode int
+	// Body is the start of the response body, truncated to 512 bytes
+	Body string
+	Err  error
+}
+
+// Error returns a description of the failed request
+func (e *APIError) Error() string {
+	msg := e.Method + " " + e.Path + ": " + e.Err.Error() + " (status " + strconv.Itoa(e.StatusCode) + ")"
+	if len(e.Body) > 0 {
+		msg += ": " + e.Body
+	}
+	return msg
+}
+
+// Unwrap returns the package error corresponding to the status code
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+func newAPIError(r *http.Request, resp *http.Response) *APIError {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyLength))
+
+	return &APIError{
+		Method:     r.Method,
+		Path:       redactPath(r.URL.Path),
+		StatusCode: resp.StatusCode,
+		Body:       strings.TrimSpace(string(body)),
+		Err:        statusError(resp.StatusCode),
+	}
+}
+
+func statusError(statusCode int) error {
+	switch statusCode {
+	case 400, 422:
+		return ErrBadRequest
+	case 401:
+		return ErrUnauthorized
+	case 403:
+		return ErrForbidden
+	case 404:
+		return ErrNotFound
+	}
+	return ErrUnknown
+}
+
+// ReplaceAPIKey replaces the API key in a path of the form [prefix]/api/v1/<key>/...,
+// returning the updated path and the key which was replaced (empty if the path has no key)
+func ReplaceAPIKey(path string, replacement string) (string, string) {
+	parts := strings.Split(path, "/")
+	for i := 0; i+2 < len(parts); i++ {
+		if parts[i] == "api" && parts[i+1] == "v1" && parts[i+2] != "new" && len(parts[i+2]) > 0 {
+			key := parts[i+2]
+			parts[i+2] = replacement
+			return strings.Join(parts, "/"), key
+		}
+	}
+	return path, ""
+}
+
+// redactPath replaces the API key in a path so it can be included in errors and logs
+func redactPath(path string) string {
+	redacted, _ := ReplaceAPIKey(path, "<redacted>")
+	return redacted
+}
+
+// redactURL returns the path of the URL with the API key redacted
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "<invalid url>"
+	}
+	return redactPath(u.Path)
+}
+
+// redactTransportError removes the API key from the URL included in errors returned by the HTTP client
+func redactTransportError(err error) error {
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) {
+		return err
+	}
+
+	u, parseErr := url.Parse(urlErr.URL)
+	if parseErr != nil {
+		urlErr.URL = "<invalid url>"
+		return err
+	}
+
+	redacted := u.Scheme + "://" + u.Host + redactPath(u.Path)
+	if len(u.RawQuery) > 0 {
+		redacted += "?" + u.RawQuery
+	}
+	urlErr.URL = redacted
+	return err
+}
@@ -0,0 +1,81 @@
+package nanoleaf
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestRedactPath(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"/api/v1/SECRET/state", "/api/v1/<redacted>/state"},
+		{"/api/v1/SECRET", "/api/v1/<redacted>"},
+		{"/api/v1/SECRET/", "/api/v1/<redacted>/"},
+		{"/proxy/panel/api/v1/SECRET/effects", "/proxy/panel/api/v1/<redacted>/effects"},
+		{"/api/v1/new", "/api/v1/new"},
+		{"/api/v1/", "/api/v1/"},
+		{"/other", "/other"},
+	}
+
+	for _, test := range tests {
+		if actual := redactPath(test.path); actual != test.expected {
+			t.Errorf("expected %s to be redacted to %s, got %s", test.path, test.expected, actual)
+		}
+
+		// The key is only returned if one was replaced
+		_, key := ReplaceAPIKey(test.path, "<redacted>")
+		if hasKey := test.path != test.expected; hasKey != (key == "SECRET") || (!hasKey && len(key) > 0) {
+			t.Errorf("expected the key in %s to be returned, got %q", test.path, key)
+		}
+	}
+}
+
+func TestRedactTransportError(t *testing.T) {
+	err := redactTransportError(&url.Error{
+		Op:  "Get",
+		URL: "http://192.168.1.10:16021/api/v1/SECRET/events?id=1,2",
+		Err: errors.New("connection refused"),
+	})
+
+	expected := `Get "http://192.168.1.10:16021/api/v1/<redacted>/events?id=1,2": connection refused`
+	if err.Error() != expected {
+		t.Fatalf("expected %s, got %s", expected, err.Error())
+	}
+
+	other := errors.New("other")
+	if redactTransportError(other) != other {
+		t.Fatal("expected errors without a URL to be returned unchanged")
+	}
+}
+
+func TestNetworkErrorsRedactAPIKey(t *testing.T) {
+	// Nothing listens on port 1, so the connection is refused
+	c, err := New("127.0.0.1", WithPort(1), WithAPIKey("SECRETKEY123"), WithRetryPolicy(RetryPolicy{MaxAttempts: 1}))
+	if err != nil {
+		t.Fatalf("error creating client: %s", err)
+	}
+
+	_, err = c.GetPanel(context.Background())
+	if err == nil {
+		t.Fatal("expected an error")
+	} else if strings.Contains(err.Error(), "SECRETKEY123") {
+		t.Fatalf("expected the API key to be redacted, got %s", err.Error())
+	}
+
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) {
+		t.Fatalf("expected a *url.Error, got %T", err)
+	}
+
+	_, err = c.Subscribe(context.Background())
+	if err == nil {
+		t.Fatal("expected an error")
+	} else if strings.Contains(err.Error(), "SECRETKEY123") {
+		t.Fatalf("expected the API key to be redacted, got %s", err.Error())
+	}
+}
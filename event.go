@@ -1,6 +1,144 @@
 package nanoleaf
 
-import "encoding/json"
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// EventType identifies a category of events which can be subscribed to
+type EventType int
+
+const (
+	// EventTypeState is sent when the on/brightness/colour state changes
+	EventTypeState EventType = 1
+	// EventTypeLayout is sent when the panel layout or orientation changes
+	EventTypeLayout EventType = 2
+	// EventTypeEffects is sent when the selected effect changes
+	EventTypeEffects EventType = 3
+	// EventTypeTouch is sent when a touch gesture is detected on supported panels
+	EventTypeTouch EventType = 4
+)
+
+// Subscribe opens the event stream of the panel and returns a channel of the updates received.
+// If no types are specified all event types are subscribed to.
+// The channel is closed when the context is cancelled or the stream is terminated by the panel.
+func (c *Client) Subscribe(ctx context.Context, types ...EventType) (<-chan PanelUpdate, error) {
+	resp, err := c.openEvents(ctx, types, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := make(chan PanelUpdate)
+	go func() {
+		defer close(updates)
+		defer resp.Body.Close()
+
+		readEvents(resp.Body, func(update PanelUpdate) bool {
+			select {
+			case updates <- update:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+	}()
+
+	return updates, nil
+}
+
+// openEvents starts the event stream, sending any additional headers with the request
+func (c *Client) openEvents(ctx context.Context, types []EventType, header http.Header) (*http.Response, error) {
+	if len(types) < 1 {
+		types = []EventType{EventTypeState, EventTypeLayout, EventTypeEffects, EventTypeTouch}
+	}
+
+	var ids []string
+	for _, t := range types {
+		ids = append(ids, strconv.Itoa(int(t)))
+	}
+
+	r, err := c.newRequest(ctx, http.MethodGet, c.getURLBase()+"events?id="+strings.Join(ids, ","), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, values := range header {
+		r.Header[name] = values
+	}
+	r.Header.Set("Accept", "text/event-stream")
+
+	resp, err := c.httpClient.Do(r)
+	if err != nil {
+		return nil, redactTransportError(err)
+	}
+
+	if resp.StatusCode == 200 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	return nil, newAPIError(r, resp)
+}
+
+// readEvents parses the server-sent event framing of the stream, decoding each event into a PanelUpdate.
+// The event 'id' field carries the type ID which is required to decode the event data.
+// Events which can't be decoded are skipped. Reading stops when the stream ends or handler returns false.
+func readEvents(r io.Reader, handler func(PanelUpdate) bool) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), 1024*1024)
+
+	var id string
+	var data bytes.Buffer
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if len(line) < 1 {
+			// A blank line dispatches the buffered event
+			if data.Len() > 0 {
+				typeID, err := strconv.Atoi(id)
+				if err == nil {
+					update := PanelUpdate{
+						TypeID: typeID,
+					}
+					if err = json.Unmarshal(data.Bytes(), &update); err == nil && !handler(update) {
+						return nil
+					}
+				}
+			}
+
+			id = ""
+			data.Reset()
+			continue
+		} else if strings.HasPrefix(line, ":") {
+			// Comment lines are used as keep-alives
+			continue
+		}
+
+		field, value := line, ""
+		if idx := strings.Index(line, ":"); idx >= 0 {
+			field, value = line[:idx], strings.TrimPrefix(line[idx+1:], " ")
+		}
+
+		switch field {
+		case "id":
+			id = strings.TrimSpace(value)
+		case "data":
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(value)
+		}
+	}
+
+	return scanner.Err()
+}
 
 // PanelUpdate contains the update for a given panel event.
 // typeID must be set before deserializing the update as JSON
@@ -18,6 +156,10 @@ type PanelUpdate struct {
 
 	// Gestures will be nonempty if typeID is set to 4
 	Gestures []Gesture
+
+	// Synthetic is set if the update was generated from the full panel state after reconnecting
+	// rather than being received from the event stream
+	Synthetic bool
 }
 
 // UnmarshalJSON allows us to decode the contents of the event
@@ -116,7 +258,7 @@ func (pu *PanelUpdate) UnmarshalJSON(b []byte) error {
 
 // Gesture represents a detected touch event on supported panels.
 type Gesture struct {
-	GestureType int `json:"gesture"`
+	GestureType GestureType `json:"gesture"`
 	// PanelID may be set to -1 if the specified gesture can't be targeted to a panel
 	PanelID int `json:"panelId"`
 }
@@ -0,0 +1,115 @@
+package nanoleaf_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/rmrobinson/nanoleaf-go"
+	"github.com/rmrobinson/nanoleaf-go/nanoleaftest"
+)
+
+func TestSubscribeDecodesEvents(t *testing.T) {
+	controller := nanoleaftest.NewController()
+	defer controller.Close()
+	client := controller.Client()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	updates, err := client.Subscribe(ctx)
+	if err != nil {
+		t.Fatalf("error subscribing: %s", err)
+	}
+
+	// Events which can't be decoded are skipped
+	controller.SendEvent(nanoleaf.EventTypeState, "not json")
+	controller.SendEvent(nanoleaf.EventTypeLayout, `{"events":[{"attr":1,"value":"not a layout"}]}`)
+
+	if err = client.SetBrightness(ctx, 30, 0); err != nil {
+		t.Fatalf("error setting brightness: %s", err)
+	}
+	update := nextUpdate(t, updates)
+	if update.TypeID != int(nanoleaf.EventTypeState) || update.State == nil || update.State.Brightness == nil || update.State.Brightness.Value != 30 {
+		t.Fatalf("expected a brightness state update, got %+v", update)
+	}
+	if update.State.On != nil || update.State.Hue != nil || update.Synthetic {
+		t.Fatalf("expected only the brightness to be set, got %+v", update.State)
+	}
+
+	if err = client.SetCT(ctx, 4000); err != nil {
+		t.Fatalf("error setting colour temperature: %s", err)
+	}
+	update = nextUpdate(t, updates)
+	if update.State == nil || update.State.CT == nil || update.State.ColorMode == nil || *update.State.ColorMode != "ct" {
+		t.Fatalf("expected a colour temperature and mode update, got %+v", update.State)
+	}
+	update = nextUpdate(t, updates)
+	if update.TypeID != int(nanoleaf.EventTypeEffects) || update.Effect == nil || update.Effect.Current != nanoleaftest.SolidEffect {
+		t.Fatalf("expected the solid effect to be selected, got %+v", update)
+	}
+
+	layout := controller.Panel().Layout
+	layout.Orientation.Value = 90
+	layout.Panels.Panels = layout.Panels.Panels[:2]
+	layout.Panels.PanelCount = 2
+	controller.SetLayout(layout)
+	update = nextUpdate(t, updates)
+	if update.TypeID != int(nanoleaf.EventTypeLayout) || update.Layout == nil {
+		t.Fatalf("expected a layout update, got %+v", update)
+	}
+	if update.Layout.Orientation.Value != 90 || !reflect.DeepEqual(update.Layout.Panels.Panels, layout.Panels.Panels) {
+		t.Fatalf("expected layout %+v, got %+v", layout, update.Layout)
+	}
+
+	if err = client.SetScene(ctx, "Forest"); err != nil {
+		t.Fatalf("error setting scene: %s", err)
+	}
+	update = nextUpdate(t, updates)
+	if update.State == nil || update.State.ColorMode == nil || *update.State.ColorMode != "effect" {
+		t.Fatalf("expected the colour mode to switch to effect, got %+v", update)
+	}
+	update = nextUpdate(t, updates)
+	if update.TypeID != int(nanoleaf.EventTypeEffects) || update.Effect == nil || update.Effect.Current != "Forest" {
+		t.Fatalf("expected an effect update, got %+v", update)
+	}
+
+	gestures := []nanoleaf.Gesture{
+		{GestureType: nanoleaf.GestureSingleTap, PanelID: 101},
+		{GestureType: nanoleaf.GestureSwipeLeft, PanelID: -1},
+	}
+	controller.SendGestures(gestures...)
+	update = nextUpdate(t, updates)
+	if update.TypeID != int(nanoleaf.EventTypeTouch) || !reflect.DeepEqual(update.Gestures, gestures) {
+		t.Fatalf("expected gestures %+v, got %+v", gestures, update)
+	}
+}
+
+func TestSubscribeFiltersEventTypes(t *testing.T) {
+	controller := nanoleaftest.NewController()
+	defer controller.Close()
+	client := controller.Client()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	updates, err := client.Subscribe(ctx, nanoleaf.EventTypeEffects)
+	if err != nil {
+		t.Fatalf("error subscribing: %s", err)
+	}
+
+	if err = client.SetOn(ctx, false); err != nil {
+		t.Fatalf("error turning off: %s", err)
+	}
+	if err = client.SetScene(ctx, "Forest"); err != nil {
+		t.Fatalf("error setting scene: %s", err)
+	}
+
+	update := nextUpdate(t, updates)
+	if update.TypeID != int(nanoleaf.EventTypeEffects) || update.Effect == nil || update.Effect.Current != "Forest" {
+		t.Fatalf("expected only the effect update, got %+v", update)
+	}
+
+	cancel()
+	for range updates {
+	}
+}
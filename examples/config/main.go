@@ -8,7 +8,6 @@ import (
 	"time"
 
 	"github.com/davecgh/go-spew/spew"
-	"github.com/koron/go-ssdp"
 	"github.com/rmrobinson/nanoleaf-go"
 )
 
@@ -18,39 +17,40 @@ func main() {
 		port   = flag.Int("port", 16021, "The port of the panel")
 		apiKey = flag.String("apiKey", "", "The API key of the panel")
 
-		discover = flag.Bool("discover", false, "Whether to run the discovery tool")
-		create   = flag.Bool("create", false, "Whether to create a new API key or not")
-		delete   = flag.Bool("delete", false, "Whether to delete the other API key")
-		delKey   = flag.String("delKey", "", "The API key to delete")
+		discover    = flag.Bool("discover", false, "Whether to run the discovery tool")
+		create      = flag.Bool("create", false, "Whether to create a new API key or not")
+		pairTimeout = flag.Duration("pairTimeout", time.Minute, "How long to wait for the pairing window to open when creating an API key")
+		delete      = flag.Bool("delete", false, "Whether to delete the other API key")
+		delKey      = flag.String("delKey", "", "The API key to delete")
+		rotate      = flag.Bool("rotate", false, "Whether to replace the API key with a newly created one")
 	)
 	flag.Parse()
 
 	if *discover {
-		m := &ssdp.Monitor{
-			Alive: func(msg *ssdp.AliveMessage) {
-				fmt.Printf("alive; from=%s, type=%s, server=%s, location=%s\n", msg.From.String(), msg.Type, msg.Server, msg.Location)
-			},
-			Bye: func(msg *ssdp.ByeMessage) {
-				fmt.Printf("bye; from=%s, type=%s\n", msg.From.String(), msg.Type)
-			},
-			Search: func(msg *ssdp.SearchMessage) {
-				fmt.Printf("search; from=%s type=%s\n", msg.From.String(), msg.Type)
-			},
-		}
-
-		if err := m.Start(); err != nil {
-			fmt.Printf("error running ssdp: %s\n", err.Error())
+		devices, err := nanoleaf.Discover(context.Background())
+		if err != nil {
+			fmt.Printf("error discovering panels: %s\n", err.Error())
 			return
 		}
 
-		time.Sleep(time.Second * 90)
+		for _, device := range devices {
+			fmt.Printf("found; name=%s, model=%s, id=%s, host=%s, port=%d\n", device.Name, device.Model, device.DeviceID, device.Host, device.Port)
+		}
 		return
 	}
 
 	c := nanoleaf.NewClient(&http.Client{}, *host, *port, *apiKey)
 
 	if *create {
-		key, err := c.CreateAPIKey(context.Background())
+		ctx, cancel := context.WithTimeout(context.Background(), *pairTimeout)
+		defer cancel()
+
+		fmt.Printf("hold the power button on the controller for 5-7 seconds\n")
+		key, err := c.Pair(ctx, nanoleaf.PairOptions{
+			Progress: func(attempt int, err error) {
+				fmt.Printf("waiting for pairing (attempt %d)\n", attempt)
+			},
+		})
 		if err != nil {
 			fmt.Printf("error creating API key: %s\n", err.Error())
 			return
@@ -69,6 +69,20 @@ func main() {
 		fmt.Printf("deleted api key\n")
 	}
 
+	if *rotate {
+		ctx, cancel := context.WithTimeout(context.Background(), *pairTimeout)
+		defer cancel()
+
+		fmt.Printf("hold the power button on the controller for 5-7 seconds\n")
+		key, err := c.RotateAPIKey(ctx, nanoleaf.PairOptions{})
+		if err != nil {
+			fmt.Printf("error rotating API key: %s\n", err.Error())
+			return
+		}
+
+		fmt.Printf("rotated to new API key %s\n", key)
+	}
+
 	gw, err := c.GetPanel(context.Background())
 	if err != nil {
 		fmt.Printf("err getting panel: %s\n", err.Error())
@@ -16,13 +16,33 @@ func main() {
 		port   = flag.Int("port", 16021, "The port of the panel")
 		apiKey = flag.String("apiKey", "", "The API key of the panel")
 
-		name = flag.String("name", "", "The name of the effect to operate on")
+		name    = flag.String("name", "", "The name of the effect to operate on")
+		newName = flag.String("newName", "", "The new name to give the effect")
+		delete  = flag.Bool("delete", false, "Whether to delete the effect")
 	)
 	flag.Parse()
 
 	c := nanoleaf.NewClient(&http.Client{}, *host, *port, *apiKey)
 
-	if len(*name) > 0 {
+	if len(*name) > 0 && *delete {
+		err := c.DeleteEffect(context.Background(), *name)
+		if err != nil {
+			fmt.Printf("err deleting effect: %s\n", err.Error())
+			return
+		}
+
+		fmt.Printf("effect deleted\n")
+		return
+	} else if len(*name) > 0 && len(*newName) > 0 {
+		err := c.RenameEffect(context.Background(), *name, *newName)
+		if err != nil {
+			fmt.Printf("err renaming effect: %s\n", err.Error())
+			return
+		}
+
+		fmt.Printf("effect renamed\n")
+		return
+	} else if len(*name) > 0 {
 		effect, err := c.GetEffect(context.Background(), *name)
 		if err != nil {
 			fmt.Printf("err getting effect: %s\n", err.Error())
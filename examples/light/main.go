@@ -16,11 +16,12 @@ func main() {
 		port   = flag.Int("port", 16021, "The port of the panel")
 		apiKey = flag.String("apiKey", "", "The API key of the panel")
 
-		isOn  = flag.Bool("isOn", false, "Whether to turn the light on or off")
-		scene = flag.String("scene", "", "The name of the scene to apply")
+		isOn       = flag.Bool("isOn", false, "Whether to turn the light on or off")
+		scene      = flag.String("scene", "", "The name of the scene to apply")
+		hue        = flag.Int("hue", -1, "The hue to set")
+		sat        = flag.Int("sat", -1, "The saturation level to set")
+		brightness = flag.Int("brightness", -1, "The brightness level to set")
 		/*
-			hue  = flag.Int("hue", 0, "The hue to set")
-			sat  = flag.Int("sat", 0, "The saturation level to set")
 			name = flag.String("name", "", "The name of the device to set")
 		*/
 		orientation = flag.Int("orientation", -1, "The orientation to set")
@@ -33,7 +34,18 @@ func main() {
 	c := nanoleaf.NewClient(&http.Client{}, *host, *port, *apiKey)
 
 	if *setState {
-		err := c.SetOn(context.Background(), *isOn)
+		update := nanoleaf.NewStateUpdate().On(*isOn)
+		if *hue >= 0 {
+			update = update.Hue(*hue)
+		}
+		if *sat >= 0 {
+			update = update.Saturation(*sat)
+		}
+		if *brightness >= 0 {
+			update = update.Brightness(*brightness, 0)
+		}
+
+		err := c.UpdateState(context.Background(), update)
 		if err != nil {
 			fmt.Printf("error setting light on: %s\n", err.Error())
 			return
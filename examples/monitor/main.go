@@ -1,13 +1,12 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
 	"flag"
 	"fmt"
-	"strconv"
+	"net/http"
 
 	"github.com/davecgh/go-spew/spew"
-	"github.com/r3labs/sse"
 	"github.com/rmrobinson/nanoleaf-go"
 )
 
@@ -19,26 +18,17 @@ func main() {
 	)
 	flag.Parse()
 
-	client := sse.NewClient("http://" + *host + ":" + strconv.Itoa(*port) + "/api/v1/" + *apiKey + "/events?id=1,3")
+	c := nanoleaf.NewClient(&http.Client{}, *host, *port, *apiKey)
 
-	client.SubscribeRaw(func(msg *sse.Event) {
-		id, err := strconv.Atoi(string(msg.ID))
-		if err != nil {
-			fmt.Printf("error converting ID to string: %s\n", err.Error())
-			return
-		}
-
-		update := &nanoleaf.PanelUpdate{
-			TypeID: id,
-		}
-
-		err = json.Unmarshal(msg.Data, update)
-		if err != nil {
-			fmt.Printf("error unmarshaling: %s\n", err.Error())
-			return
-		}
+	updates, err := c.Subscribe(context.Background(), nanoleaf.EventTypeState, nanoleaf.EventTypeEffects)
+	if err != nil {
+		fmt.Printf("error subscribing to events: %s\n", err.Error())
+		return
+	}
 
+	for update := range updates {
 		spew.Dump(update)
-	})
+	}
 
+	fmt.Printf("event stream closed\n")
 }
@@ -0,0 +1,230 @@
+package nanoleaf
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultFleetParallelism is the number of controllers a fleet operates on at once, unless otherwise set
+const DefaultFleetParallelism = 4
+
+// Fleet manages many controllers, identified by their serial numbers, applying operations to all of them at once
+type Fleet struct {
+	// Parallelism limits the number of controllers operated on at once (defaults to DefaultFleetParallelism)
+	Parallelism int
+
+	lock    sync.RWMutex
+	clients map[string]*Client
+}
+
+// FleetError is returned when an operation failed on some of the controllers in a fleet
+type FleetError struct {
+	// Errors holds the error of each failed controller, by serial number
+	Errors map[string]error
+}
+
+// Error returns a description of each failure, ordered by serial number
+func (e *FleetError) Error() string {
+	var failures []string
+	for _, serialNumber := range e.serialNumbers() {
+		failures = append(failures, serialNumber+": "+e.Errors[serialNumber].Error())
+	}
+	return strconv.Itoa(len(e.Errors)) + " controller(s) failed: " + strings.Join(failures, "; ")
+}
+
+// Unwrap returns the errors of the failed controllers, so errors.Is and errors.As match if any controller failed with the error
+func (e *FleetError) Unwrap() []error {
+	var errs []error
+	for _, serialNumber := range e.serialNumbers() {
+		errs = append(errs, e.Errors[serialNumber])
+	}
+	return errs
+}
+
+// FleetUpdate is an update received from one of the controllers in a fleet
+type FleetUpdate struct {
+	SerialNumber string
+	Update       PanelUpdate
+}
+
+// NewFleet creates an empty fleet
+func NewFleet() *Fleet {
+	return &Fleet{
+		clients: map[string]*Client{},
+	}
+}
+
+// Add retrieves the serial number of the controller and adds it to the fleet, replacing any client with the same serial number
+func (f *Fleet) Add(ctx context.Context, client *Client) (string, error) {
+	panel, err := client.GetPanel(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	f.AddWithSerial(panel.SerialNumber, client)
+	return panel.SerialNumber, nil
+}
+
+// AddWithSerial adds the controller to the fleet using a known serial number, replacing any client with the same serial number
+func (f *Fleet) AddWithSerial(serialNumber string, client *Client) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.clients[serialNumber] = client
+}
+
+// Remove removes the controller from the fleet
+func (f *Fleet) Remove(serialNumber string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	delete(f.clients, serialNumber)
+}
+
+// Client returns the client of the controller with the serial number
+func (f *Fleet) Client(serialNumber string) (*Client, bool) {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	client, ok := f.clients[serialNumber]
+	return client, ok
+}
+
+// SerialNumbers returns the serial numbers of the controllers in the fleet, in order
+func (f *Fleet) SerialNumbers() []string {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	return serialNumbers(f.clients)
+}
+
+// SetOn turns every controller in the fleet on or off
+func (f *Fleet) SetOn(ctx context.Context, on bool) error {
+	return f.Do(ctx, func(ctx context.Context, serialNumber string, client *Client) error {
+		return client.SetOn(ctx, on)
+	})
+}
+
+// SetScene selects the scene on every controller in the fleet
+func (f *Fleet) SetScene(ctx context.Context, sceneName string) error {
+	return f.Do(ctx, func(ctx context.Context, serialNumber string, client *Client) error {
+		return client.SetScene(ctx, sceneName)
+	})
+}
+
+// UpdateState applies the state changes to every controller in the fleet
+func (f *Fleet) UpdateState(ctx context.Context, update StateUpdate) error {
+	return f.Do(ctx, func(ctx context.Context, serialNumber string, client *Client) error {
+		return client.UpdateState(ctx, update)
+	})
+}
+
+// Do calls the function for every controller in the fleet, running up to Parallelism calls at once.
+// It returns once every call has completed; if any failed a *FleetError is returned.
+func (f *Fleet) Do(ctx context.Context, fn func(ctx context.Context, serialNumber string, client *Client) error) error {
+	f.lock.RLock()
+	clients := make(map[string]*Client, len(f.clients))
+	for serialNumber, client := range f.clients {
+		clients[serialNumber] = client
+	}
+	f.lock.RUnlock()
+
+	return f.do(ctx, clients, fn)
+}
+
+func (f *Fleet) do(ctx context.Context, clients map[string]*Client, fn func(ctx context.Context, serialNumber string, client *Client) error) error {
+	parallelism := f.Parallelism
+	if parallelism < 1 {
+		parallelism = DefaultFleetParallelism
+	}
+
+	var (
+		wg        sync.WaitGroup
+		errLock   sync.Mutex
+		errs      = map[string]error{}
+		semaphore = make(chan struct{}, parallelism)
+	)
+
+	for _, serialNumber := range serialNumbers(clients) {
+		select {
+		case semaphore <- struct{}{}:
+		case <-ctx.Done():
+			errLock.Lock()
+			errs[serialNumber] = ctx.Err()
+			errLock.Unlock()
+			continue
+		}
+
+		wg.Add(1)
+		go func(serialNumber string, client *Client) {
+			defer func() {
+				<-semaphore
+				wg.Done()
+			}()
+
+			if err := fn(ctx, serialNumber, client); err != nil {
+				errLock.Lock()
+				errs[serialNumber] = err
+				errLock.Unlock()
+			}
+		}(serialNumber, clients[serialNumber])
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return &FleetError{Errors: errs}
+	}
+	return nil
+}
+
+// Subscribe merges the event streams of every controller currently in the fleet, tagging each update with the serial number it came from.
+// Each stream is reconnected as described by the options if it is lost. The channel is closed once the context is cancelled.
+func (f *Fleet) Subscribe(ctx context.Context, opts ReconnectOptions, types ...EventType) <-chan FleetUpdate {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	merged := make(chan FleetUpdate)
+
+	var wg sync.WaitGroup
+	for serialNumber, client := range f.clients {
+		wg.Add(1)
+		go func(serialNumber string, updates <-chan PanelUpdate) {
+			defer wg.Done()
+
+			for update := range updates {
+				select {
+				case merged <- FleetUpdate{SerialNumber: serialNumber, Update: update}:
+				case <-ctx.Done():
+				}
+			}
+		}(serialNumber, client.SubscribeWithReconnect(ctx, opts, types...))
+	}
+
+	go func() {
+		wg.Wait()
+		close(merged)
+	}()
+
+	return merged
+}
+
+func (e *FleetError) serialNumbers() []string {
+	serialNumbers := make([]string, 0, len(e.Errors))
+	for serialNumber := range e.Errors {
+		serialNumbers = append(serialNumbers, serialNumber)
+	}
+	sort.Strings(serialNumbers)
+	return serialNumbers
+}
+
+func serialNumbers(clients map[string]*Client) []string {
+	serialNumbers := make([]string, 0, len(clients))
+	for serialNumber := range clients {
+		serialNumbers = append(serialNumbers, serialNumber)
+	}
+	sort.Strings(serialNumbers)
+	return serialNumbers
+}
@@ -0,0 +1,191 @@
+package nanoleaf_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rmrobinson/nanoleaf-go"
+	"github.com/rmrobinson/nanoleaf-go/nanoleaftest"
+)
+
+// newTestFleet adds a fake controller with each serial number to a new fleet
+func newTestFleet(t *testing.T, serialNumbers ...string) (*nanoleaf.Fleet, map[string]*nanoleaftest.Controller) {
+	t.Helper()
+
+	fleet := nanoleaf.NewFleet()
+	controllers := map[string]*nanoleaftest.Controller{}
+	for _, serialNumber := range serialNumbers {
+		controller := nanoleaftest.NewController()
+		t.Cleanup(controller.Close)
+
+		panel := controller.Panel()
+		panel.SerialNumber = serialNumber
+		controller.SetPanel(panel)
+
+		added, err := fleet.Add(context.Background(), controller.Client(nanoleaf.WithRetryPolicy(nanoleaf.RetryPolicy{MaxAttempts: 1})))
+		if err != nil {
+			t.Fatalf("error adding controller: %s", err)
+		} else if added != serialNumber {
+			t.Fatalf("expected serial number %s, got %s", serialNumber, added)
+		}
+		controllers[serialNumber] = controller
+	}
+	return fleet, controllers
+}
+
+func TestFleetParallelism(t *testing.T) {
+	fleet, controllers := newTestFleet(t, "A", "B", "C", "D", "E")
+	fleet.Parallelism = 2
+	for _, controller := range controllers {
+		controller.SetLatency(20 * time.Millisecond)
+	}
+
+	var (
+		lock      sync.Mutex
+		active    int
+		maxActive int
+	)
+	err := fleet.Do(context.Background(), func(ctx context.Context, serialNumber string, client *nanoleaf.Client) error {
+		lock.Lock()
+		active++
+		if active > maxActive {
+			maxActive = active
+		}
+		lock.Unlock()
+
+		defer func() {
+			lock.Lock()
+			active--
+			lock.Unlock()
+		}()
+		return client.SetOn(ctx, false)
+	})
+	if err != nil {
+		t.Fatalf("error turning off: %s", err)
+	}
+
+	if maxActive != 2 {
+		t.Fatalf("expected 2 controllers to be operated on at once, got %d", maxActive)
+	}
+	for serialNumber, controller := range controllers {
+		if controller.Panel().State.On.Value {
+			t.Errorf("expected controller %s to be turned off", serialNumber)
+		}
+	}
+}
+
+func TestFleetError(t *testing.T) {
+	fleet, controllers := newTestFleet(t, "A", "B", "C")
+	controllers["A"].Fail(http.MethodPut, "state", http.StatusNotFound, 1)
+	controllers["C"].Fail(http.MethodPut, "state", http.StatusUnauthorized, 1)
+
+	err := fleet.SetOn(context.Background(), false)
+
+	var fleetErr *nanoleaf.FleetError
+	if !errors.As(err, &fleetErr) {
+		t.Fatalf("expected a fleet error, got %v", err)
+	}
+	if len(fleetErr.Errors) != 2 || !errors.Is(fleetErr.Errors["A"], nanoleaf.ErrNotFound) || !errors.Is(fleetErr.Errors["C"], nanoleaf.ErrUnauthorized) {
+		t.Fatalf("expected the errors of A and C, got %v", fleetErr.Errors)
+	}
+	if !strings.HasPrefix(err.Error(), "2 controller(s) failed: A: ") || !strings.Contains(err.Error(), "; C: ") {
+		t.Fatalf("expected the failures ordered by serial number, got %s", err)
+	}
+
+	// Every failure can be matched through the fleet error
+	var apiErr *nanoleaf.APIError
+	if !errors.Is(err, nanoleaf.ErrNotFound) || !errors.Is(err, nanoleaf.ErrUnauthorized) || !errors.As(err, &apiErr) {
+		t.Fatalf("expected the controller errors to be unwrapped, got %v", err)
+	}
+
+	if controllers["B"].Panel().State.On.Value {
+		t.Fatal("expected the failures not to affect the other controllers")
+	}
+}
+
+func TestFleetCancelled(t *testing.T) {
+	fleet, controllers := newTestFleet(t, "A", "B", "C")
+	fleet.Parallelism = 1
+	controllers["A"].SetLatency(200 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	var (
+		lock   sync.Mutex
+		called []string
+	)
+	err := fleet.Do(ctx, func(ctx context.Context, serialNumber string, client *nanoleaf.Client) error {
+		lock.Lock()
+		called = append(called, serialNumber)
+		lock.Unlock()
+
+		return client.SetOn(ctx, false)
+	})
+
+	// The controllers which were waiting their turn fail with the context error without being called
+	var fleetErr *nanoleaf.FleetError
+	if !errors.As(err, &fleetErr) || len(fleetErr.Errors) != 3 {
+		t.Fatalf("expected every controller to fail, got %v", err)
+	}
+	for serialNumber, err := range fleetErr.Errors {
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected controller %s to fail with the context error, got %v", serialNumber, err)
+		}
+	}
+	if len(called) != 1 || called[0] != "A" {
+		t.Fatalf("expected only A to be called, got %v", called)
+	}
+	if !controllers["B"].Panel().State.On.Value || !controllers["C"].Panel().State.On.Value {
+		t.Fatal("expected the remaining controllers not to be changed")
+	}
+}
+
+func TestFleetSubscribe(t *testing.T) {
+	fleet, controllers := newTestFleet(t, "A", "B")
+	panelIDs := map[string]int{"A": 101, "B": 102}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	updates := fleet.Subscribe(ctx, nanoleaf.ReconnectOptions{}, nanoleaf.EventTypeTouch)
+
+	// The streams connect in the background, so gestures are sent until one arrives from every controller
+	received := map[string]bool{}
+	ticker := time.NewTicker(20 * time.Millisecond)
+	defer ticker.Stop()
+	deadline := time.After(5 * time.Second)
+
+	for len(received) < len(controllers) {
+		select {
+		case update := <-updates:
+			gestures := update.Update.Gestures
+			if len(gestures) != 1 || gestures[0].PanelID != panelIDs[update.SerialNumber] {
+				t.Fatalf("expected the gesture of controller %s, got %+v", update.SerialNumber, gestures)
+			}
+			received[update.SerialNumber] = true
+		case <-ticker.C:
+			for serialNumber, controller := range controllers {
+				controller.SendGestures(nanoleaf.Gesture{GestureType: nanoleaf.GestureSingleTap, PanelID: panelIDs[serialNumber]})
+			}
+		case <-deadline:
+			t.Fatalf("timed out waiting for updates, got %v", received)
+		}
+	}
+
+	cancel()
+	for {
+		select {
+		case _, ok := <-updates:
+			if !ok {
+				return
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("expected the updates to end once cancelled")
+		}
+	}
+}
@@ -0,0 +1,228 @@
+// Package geometry interprets the panel layout reported by a Nanoleaf controller, providing the outline
+// of each panel so the arrangement can be measured and rendered.
+package geometry
+
+import (
+	"math"
+
+	"github.com/rmrobinson/nanoleaf-go"
+)
+
+// Point is a position in the layout coordinate space, where y increases upwards
+type Point struct {
+	X float64
+	Y float64
+}
+
+// Rect is an axis-aligned rectangle in the layout coordinate space
+type Rect struct {
+	Min Point
+	Max Point
+}
+
+// Width returns the horizontal size of the rectangle
+func (r Rect) Width() float64 {
+	return r.Max.X - r.Min.X
+}
+
+// Height returns the vertical size of the rectangle
+func (r Rect) Height() float64 {
+	return r.Max.Y - r.Min.Y
+}
+
+// Polygon is the outline of a panel, with its vertices in counter-clockwise order
+type Polygon []Point
+
+// Panel is a single panel positioned in the layout
+type Panel struct {
+	ID        int
+	ShapeType nanoleaf.ShapeType
+	// Center is the position reported by the controller, after the global orientation is applied
+	Center Point
+	// Outline is empty for pieces with no size, such as the Rhythm module
+	Outline Polygon
+}
+
+type outline struct {
+	// sides is 2 for line segments, which are drawn as thin rectangles
+	sides int
+	// startAngle is the angle of the first vertex (in degrees) before the panel orientation is applied
+	startAngle float64
+}
+
+// outlines maps the shapes to their outlines; the size comes from the side length of the shape.
+// The Lines connectors, controller cap and power connector are drawn as small hexagons, matching the hubs the bars plug into.
+var outlines = map[nanoleaf.ShapeType]outline{
+	nanoleaf.ShapeTriangle:              {sides: 3, startAngle: 90},
+	nanoleaf.ShapeSquare:                {sides: 4, startAngle: 45},
+	nanoleaf.ShapeControlSquareMaster:   {sides: 4, startAngle: 45},
+	nanoleaf.ShapeControlSquarePassive:  {sides: 4, startAngle: 45},
+	nanoleaf.ShapeHexagonShapes:         {sides: 6, startAngle: 0},
+	nanoleaf.ShapeTriangleShapes:        {sides: 3, startAngle: 90},
+	nanoleaf.ShapeMiniTriangleShapes:    {sides: 3, startAngle: 90},
+	nanoleaf.ShapeElementsHexagon:       {sides: 6, startAngle: 0},
+	nanoleaf.ShapeElementsHexagonCorner: {sides: 3, startAngle: 90},
+	nanoleaf.ShapeLinesConnector:        {sides: 6, startAngle: 0},
+	nanoleaf.ShapeLightLines:            {sides: 2},
+	nanoleaf.ShapeLightLinesSingleZone:  {sides: 2},
+	nanoleaf.ShapeControllerCap:         {sides: 6, startAngle: 0},
+	nanoleaf.ShapePowerConnector:        {sides: 6, startAngle: 0},
+}
+
+// lineWidthRatio is the width of a line segment relative to its length
+const lineWidthRatio = 0.08
+
+// Outline returns the outline of the panel at the specified position, or nil if the panel has no size
+// (the Rhythm module and the Shapes controller). The orientation is applied counter-clockwise.
+func Outline(pos nanoleaf.PanelPosition) Polygon {
+	s, ok := outlines[pos.Type]
+	sideLength := pos.Type.SideLength()
+	if !ok || sideLength <= 0 {
+		return nil
+	}
+
+	center := Point{X: float64(pos.X), Y: float64(pos.Y)}
+	orientation := float64(pos.Orientation)
+
+	if s.sides == 2 {
+		halfLength := sideLength / 2
+		halfWidth := sideLength * lineWidthRatio / 2
+		rect := Polygon{
+			{X: -halfLength, Y: -halfWidth},
+			{X: halfLength, Y: -halfWidth},
+			{X: halfLength, Y: halfWidth},
+			{X: -halfLength, Y: halfWidth},
+		}
+		for i := range rect {
+			rect[i] = rotate(nanoleaf.Rotation{Degrees: orientation}, rect[i])
+			rect[i].X += center.X
+			rect[i].Y += center.Y
+		}
+		return rect
+	}
+
+	// The circumradius of a regular polygon with the specified side length
+	radius := sideLength / (2 * math.Sin(math.Pi/float64(s.sides)))
+
+	poly := make(Polygon, s.sides)
+	for i := range poly {
+		angle := (s.startAngle + orientation + float64(i)*360/float64(s.sides)) * math.Pi / 180
+		poly[i] = Point{
+			X: center.X + radius*math.Cos(angle),
+			Y: center.Y + radius*math.Sin(angle),
+		}
+	}
+	return poly
+}
+
+// Arrange positions all the panels of the layout, rotating the arrangement about its centre by the global orientation
+func Arrange(layout nanoleaf.PanelLayout) []Panel {
+	positions := layout.Panels.Panels
+	if len(positions) < 1 {
+		return nil
+	}
+
+	rotation := layout.Rotation()
+
+	panels := make([]Panel, 0, len(positions))
+	for _, pos := range positions {
+		panel := Panel{
+			ID:        pos.PanelID,
+			ShapeType: pos.Type,
+			Center:    rotate(rotation, Point{X: float64(pos.X), Y: float64(pos.Y)}),
+			Outline:   Outline(pos),
+		}
+		for i := range panel.Outline {
+			panel.Outline[i] = rotate(rotation, panel.Outline[i])
+		}
+
+		panels = append(panels, panel)
+	}
+	return panels
+}
+
+// Centroid returns the centre of mass of the polygon
+func (p Polygon) Centroid() Point {
+	if len(p) < 1 {
+		return Point{}
+	}
+
+	var area, cx, cy float64
+	for i := range p {
+		a, b := p[i], p[(i+1)%len(p)]
+		cross := a.X*b.Y - b.X*a.Y
+		area += cross
+		cx += (a.X + b.X) * cross
+		cy += (a.Y + b.Y) * cross
+	}
+
+	if math.Abs(area) < 1e-9 {
+		// Degenerate polygons fall back to the average of the vertices
+		var avg Point
+		for _, pt := range p {
+			avg.X += pt.X
+			avg.Y += pt.Y
+		}
+		return Point{X: avg.X / float64(len(p)), Y: avg.Y / float64(len(p))}
+	}
+
+	return Point{X: cx / (3 * area), Y: cy / (3 * area)}
+}
+
+// Bounds returns the bounding box of the polygon
+func (p Polygon) Bounds() Rect {
+	if len(p) < 1 {
+		return Rect{}
+	}
+
+	r := Rect{Min: p[0], Max: p[0]}
+	for _, pt := range p[1:] {
+		r = r.extend(pt)
+	}
+	return r
+}
+
+// Centroid returns the centre of the panel. Panels without an outline use their reported position.
+func (p Panel) Centroid() Point {
+	if len(p.Outline) < 1 {
+		return p.Center
+	}
+	return p.Outline.Centroid()
+}
+
+// Bounds returns the bounding box containing all the panels
+func Bounds(panels []Panel) Rect {
+	var r Rect
+	first := true
+
+	for _, panel := range panels {
+		points := panel.Outline
+		if len(points) < 1 {
+			points = Polygon{panel.Center}
+		}
+
+		for _, pt := range points {
+			if first {
+				r = Rect{Min: pt, Max: pt}
+				first = false
+				continue
+			}
+			r = r.extend(pt)
+		}
+	}
+	return r
+}
+
+func (r Rect) extend(pt Point) Rect {
+	r.Min.X = math.Min(r.Min.X, pt.X)
+	r.Min.Y = math.Min(r.Min.Y, pt.Y)
+	r.Max.X = math.Max(r.Max.X, pt.X)
+	r.Max.Y = math.Max(r.Max.Y, pt.Y)
+	return r
+}
+
+// rotate applies the rotation to the point
+func rotate(r nanoleaf.Rotation, pt Point) Point {
+	pt.X, pt.Y = r.Apply(pt.X, pt.Y)
+	return pt
+}
@@ -0,0 +1,103 @@
+package geometry
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/rmrobinson/nanoleaf-go"
+)
+
+func TestOutline(t *testing.T) {
+	tests := []struct {
+		shapeType nanoleaf.ShapeType
+		vertices  int
+	}{
+		{nanoleaf.ShapeTriangle, 3},
+		{nanoleaf.ShapeSquare, 4},
+		{nanoleaf.ShapeHexagonShapes, 6},
+		{nanoleaf.ShapeElementsHexagonCorner, 3},
+		{nanoleaf.ShapeLightLines, 4},
+		{nanoleaf.ShapeLinesConnector, 6},
+		{nanoleaf.ShapeControllerCap, 6},
+		{nanoleaf.ShapePowerConnector, 6},
+		{nanoleaf.ShapeRhythm, 0},
+		{nanoleaf.ShapeShapesController, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.shapeType.String(), func(t *testing.T) {
+			outline := Outline(nanoleaf.PanelPosition{X: 100, Y: 50, Type: test.shapeType})
+			if len(outline) != test.vertices {
+				t.Fatalf("expected %d vertices, got %d", test.vertices, len(outline))
+			}
+			if len(outline) < 1 || test.shapeType == nanoleaf.ShapeLightLines {
+				return
+			}
+
+			// Each side of the regular polygons has the side length of the shape
+			for i := range outline {
+				next := outline[(i+1)%len(outline)]
+				side := math.Hypot(next.X-outline[i].X, next.Y-outline[i].Y)
+				if math.Abs(side-test.shapeType.SideLength()) > 1e-9 {
+					t.Fatalf("expected sides of %f, got %f", test.shapeType.SideLength(), side)
+				}
+			}
+
+			if c := outline.Centroid(); math.Abs(c.X-100) > 1e-9 || math.Abs(c.Y-50) > 1e-9 {
+				t.Fatalf("expected the outline to be centred on the position, got %+v", c)
+			}
+		})
+	}
+}
+
+func TestArrangeAppliesOrientation(t *testing.T) {
+	layout := nanoleaf.PanelLayout{
+		Orientation: nanoleaf.IntRangeValue{Value: 90, Max: 360},
+		Panels: nanoleaf.Layout{
+			Panels: []nanoleaf.PanelPosition{
+				{PanelID: 1, X: 0, Y: 0, Type: nanoleaf.ShapeSquare},
+				{PanelID: 2, X: 100, Y: 0, Type: nanoleaf.ShapeSquare},
+			},
+		},
+	}
+
+	// Rotating counter-clockwise about (50, 0) stacks the panels vertically
+	expected := map[int]Point{1: {X: 50, Y: -50}, 2: {X: 50, Y: 50}}
+	for _, panel := range Arrange(layout) {
+		want := expected[panel.ID]
+		if math.Abs(panel.Center.X-want.X) > 1e-9 || math.Abs(panel.Center.Y-want.Y) > 1e-9 {
+			t.Errorf("expected panel %d at %+v, got %+v", panel.ID, want, panel.Center)
+		}
+		if c := panel.Centroid(); math.Abs(c.X-want.X) > 1e-9 || math.Abs(c.Y-want.Y) > 1e-9 {
+			t.Errorf("expected the outline of panel %d to rotate with it, got centroid %+v", panel.ID, c)
+		}
+	}
+}
+
+func TestRenderSVGIncludesConnectors(t *testing.T) {
+	layout := nanoleaf.PanelLayout{
+		Panels: nanoleaf.Layout{
+			Panels: []nanoleaf.PanelPosition{
+				{PanelID: 1, X: 0, Y: 0, Type: nanoleaf.ShapeLinesConnector},
+				{PanelID: 2, X: 77, Y: 0, Type: nanoleaf.ShapeLightLines},
+				{PanelID: 3, X: 154, Y: 0, Type: nanoleaf.ShapeControllerCap},
+			},
+		},
+	}
+
+	var b bytes.Buffer
+	if err := RenderSVG(&b, Arrange(layout), RenderOptions{Labels: true}); err != nil {
+		t.Fatalf("error rendering: %s", err)
+	}
+
+	svg := b.String()
+	if count := strings.Count(svg, "<polygon"); count != 3 {
+		t.Fatalf("expected 3 polygons, got %d", count)
+	}
+	// Only the light bar is labelled
+	if count := strings.Count(svg, "<text"); count != 1 {
+		t.Fatalf("expected 1 label, got %d", count)
+	}
+}
@@ -0,0 +1,203 @@
+package geometry
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/rmrobinson/nanoleaf-go"
+)
+
+var (
+	defaultFill   = color.RGBA{R: 0xdd, G: 0xdd, B: 0xdd, A: 0xff}
+	defaultStroke = color.RGBA{R: 0x33, G: 0x33, B: 0x33, A: 0xff}
+)
+
+// RenderOptions controls how a layout is rendered
+type RenderOptions struct {
+	// Colors sets the fill of panels by panel ID; panels without a colour are drawn light grey
+	Colors map[int]color.Color
+	// Scale is the number of output units (pixels for PNG) per layout unit (defaults to 1)
+	Scale float64
+	// Margin is the space left around the panels, in layout units (defaults to 10)
+	Margin float64
+	// Labels draws the panel ID at the centre of each panel with LEDs (SVG only)
+	Labels bool
+}
+
+func (o RenderOptions) withDefaults() RenderOptions {
+	if o.Scale <= 0 {
+		o.Scale = 1
+	}
+	if o.Margin <= 0 {
+		o.Margin = 10
+	}
+	return o
+}
+
+func (o RenderOptions) fill(panelID int) color.Color {
+	if c, ok := o.Colors[panelID]; ok && c != nil {
+		return c
+	}
+	return defaultFill
+}
+
+// canvas maps layout coordinates onto output coordinates, where y increases downwards
+type canvas struct {
+	bounds Rect
+	scale  float64
+	margin float64
+}
+
+func newCanvas(panels []Panel, opts RenderOptions) canvas {
+	return canvas{
+		bounds: Bounds(panels),
+		scale:  opts.Scale,
+		margin: opts.Margin,
+	}
+}
+
+func (c canvas) size() (float64, float64) {
+	return (c.bounds.Width() + 2*c.margin) * c.scale, (c.bounds.Height() + 2*c.margin) * c.scale
+}
+
+func (c canvas) project(pt Point) Point {
+	return Point{
+		X: (pt.X - c.bounds.Min.X + c.margin) * c.scale,
+		Y: (c.bounds.Max.Y - pt.Y + c.margin) * c.scale,
+	}
+}
+
+// RenderSVG writes the panels as an SVG image
+func RenderSVG(w io.Writer, panels []Panel, opts RenderOptions) error {
+	opts = opts.withDefaults()
+	cv := newCanvas(panels, opts)
+	width, height := cv.size()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%.0f\" height=\"%.0f\" viewBox=\"0 0 %.2f %.2f\">\n", math.Ceil(width), math.Ceil(height), width, height)
+
+	for _, panel := range panels {
+		if len(panel.Outline) < 1 {
+			continue
+		}
+
+		points := make([]string, len(panel.Outline))
+		for i, pt := range panel.Outline {
+			p := cv.project(pt)
+			points[i] = fmt.Sprintf("%.2f,%.2f", p.X, p.Y)
+		}
+
+		fmt.Fprintf(&b, "  <polygon data-panel-id=\"%d\" points=\"%s\" fill=\"%s\" stroke=\"%s\" stroke-width=\"%.2f\"/>\n",
+			panel.ID, strings.Join(points, " "), nanoleaf.Hex(opts.fill(panel.ID)), nanoleaf.Hex(defaultStroke), opts.Scale)
+	}
+
+	if opts.Labels {
+		for _, panel := range panels {
+			// Connectors are too small to label, and can't be addressed by their ID anyway
+			if len(panel.Outline) < 1 || !panel.ShapeType.HasLEDs() {
+				continue
+			}
+
+			p := cv.project(panel.Centroid())
+			fmt.Fprintf(&b, "  <text x=\"%.2f\" y=\"%.2f\" font-size=\"%.2f\" text-anchor=\"middle\" dominant-baseline=\"middle\">%d</text>\n",
+				p.X, p.Y, 12*opts.Scale, panel.ID)
+		}
+	}
+
+	b.WriteString("</svg>\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Rasterize draws the panels onto a new image
+func Rasterize(panels []Panel, opts RenderOptions) *image.RGBA {
+	opts = opts.withDefaults()
+	cv := newCanvas(panels, opts)
+	width, height := cv.size()
+
+	img := image.NewRGBA(image.Rect(0, 0, int(math.Ceil(width)), int(math.Ceil(height))))
+	draw.Draw(img, img.Bounds(), image.Transparent, image.Point{}, draw.Src)
+
+	for _, panel := range panels {
+		if len(panel.Outline) < 1 {
+			continue
+		}
+
+		projected := make(Polygon, len(panel.Outline))
+		for i, pt := range panel.Outline {
+			projected[i] = cv.project(pt)
+		}
+
+		// The outline is drawn by filling the panel with the stroke colour, then filling a slightly smaller copy
+		fillPolygon(img, projected, defaultStroke)
+		fillPolygon(img, shrink(projected, opts.Scale), opts.fill(panel.ID))
+	}
+
+	return img
+}
+
+// RenderPNG writes the panels as a PNG image
+func RenderPNG(w io.Writer, panels []Panel, opts RenderOptions) error {
+	return png.Encode(w, Rasterize(panels, opts))
+}
+
+// shrink moves each vertex of the convex polygon towards its centroid by the specified distance
+func shrink(poly Polygon, distance float64) Polygon {
+	center := poly.Centroid()
+
+	shrunk := make(Polygon, len(poly))
+	for i, pt := range poly {
+		dx, dy := pt.X-center.X, pt.Y-center.Y
+		length := math.Hypot(dx, dy)
+		if length <= distance {
+			shrunk[i] = center
+			continue
+		}
+
+		ratio := (length - distance) / length
+		shrunk[i] = Point{X: center.X + dx*ratio, Y: center.Y + dy*ratio}
+	}
+	return shrunk
+}
+
+// fillPolygon fills the polygon using an even-odd scanline fill, sampling the centre of each pixel
+func fillPolygon(img *image.RGBA, poly Polygon, c color.Color) {
+	bounds := poly.Bounds()
+	minY := int(math.Max(math.Floor(bounds.Min.Y), float64(img.Rect.Min.Y)))
+	maxY := int(math.Min(math.Ceil(bounds.Max.Y), float64(img.Rect.Max.Y-1)))
+
+	src := image.NewUniform(c)
+	var crossings []float64
+
+	for y := minY; y <= maxY; y++ {
+		sampleY := float64(y) + 0.5
+		crossings = crossings[:0]
+
+		for i := range poly {
+			a, b := poly[i], poly[(i+1)%len(poly)]
+			if (a.Y <= sampleY) == (b.Y <= sampleY) {
+				continue
+			}
+			crossings = append(crossings, a.X+(sampleY-a.Y)*(b.X-a.X)/(b.Y-a.Y))
+		}
+		sort.Float64s(crossings)
+
+		for i := 0; i+1 < len(crossings); i += 2 {
+			start := int(math.Ceil(crossings[i] - 0.5))
+			end := int(math.Floor(crossings[i+1] - 0.5))
+			if end < start {
+				continue
+			}
+
+			draw.Draw(img, image.Rect(start, y, end+1, y+1), src, image.Point{}, draw.Over)
+		}
+	}
+}
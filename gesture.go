@@ -0,0 +1,123 @@
+package nanoleaf
+
+import (
+	"context"
+	"strconv"
+	"sync"
+)
+
+// GestureType identifies the touch gesture detected by the panels
+type GestureType int
+
+const (
+	// GestureSingleTap is a single tap on a panel
+	GestureSingleTap GestureType = 0
+	// GestureDoubleTap is two taps in quick succession on a panel
+	GestureDoubleTap GestureType = 1
+	// GestureSwipeUp is a swipe upwards across the panels
+	GestureSwipeUp GestureType = 2
+	// GestureSwipeDown is a swipe downwards across the panels
+	GestureSwipeDown GestureType = 3
+	// GestureSwipeLeft is a swipe to the left across the panels
+	GestureSwipeLeft GestureType = 4
+	// GestureSwipeRight is a swipe to the right across the panels
+	GestureSwipeRight GestureType = 5
+)
+
+// String returns the name of the gesture
+func (gt GestureType) String() string {
+	switch gt {
+	case GestureSingleTap:
+		return "Single Tap"
+	case GestureDoubleTap:
+		return "Double Tap"
+	case GestureSwipeUp:
+		return "Swipe Up"
+	case GestureSwipeDown:
+		return "Swipe Down"
+	case GestureSwipeLeft:
+		return "Swipe Left"
+	case GestureSwipeRight:
+		return "Swipe Right"
+	}
+	return "GestureType(" + strconv.Itoa(int(gt)) + ")"
+}
+
+// GestureHandler is called with a gesture received from the panels
+type GestureHandler func(Gesture)
+
+type panelGesture struct {
+	panelID int
+	gesture GestureType
+}
+
+// GestureDispatcher calls the handlers registered for the gestures contained in touch event updates.
+// Handlers can be registered either for a gesture on any panel or for a gesture on a specific panel.
+type GestureDispatcher struct {
+	lock     sync.RWMutex
+	anyPanel map[GestureType][]GestureHandler
+	byPanel  map[panelGesture][]GestureHandler
+}
+
+// NewGestureDispatcher creates a dispatcher with no handlers
+func NewGestureDispatcher() *GestureDispatcher {
+	return &GestureDispatcher{
+		anyPanel: map[GestureType][]GestureHandler{},
+		byPanel:  map[panelGesture][]GestureHandler{},
+	}
+}
+
+// Handle registers a handler for the gesture regardless of which panel (if any) it is detected on
+func (d *GestureDispatcher) Handle(gesture GestureType, handler GestureHandler) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.anyPanel[gesture] = append(d.anyPanel[gesture], handler)
+}
+
+// HandlePanel registers a handler for the gesture when it is detected on the specified panel.
+// Swipes are generally not attributed to a panel so will not match.
+func (d *GestureDispatcher) HandlePanel(panelID int, gesture GestureType, handler GestureHandler) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	key := panelGesture{panelID: panelID, gesture: gesture}
+	d.byPanel[key] = append(d.byPanel[key], handler)
+}
+
+// Dispatch calls the handlers matching each gesture in the update; updates of other types are ignored.
+// Panel specific handlers are called before those registered for any panel.
+func (d *GestureDispatcher) Dispatch(update PanelUpdate) {
+	if update.TypeID != int(EventTypeTouch) {
+		return
+	}
+
+	for _, gesture := range update.Gestures {
+		d.lock.RLock()
+		var handlers []GestureHandler
+		if gesture.PanelID >= 0 {
+			handlers = append(handlers, d.byPanel[panelGesture{panelID: gesture.PanelID, gesture: gesture.GestureType}]...)
+		}
+		handlers = append(handlers, d.anyPanel[gesture.GestureType]...)
+		d.lock.RUnlock()
+
+		for _, handler := range handlers {
+			handler(gesture)
+		}
+	}
+}
+
+// Run dispatches the updates received on the channel until it is closed or the context is done
+func (d *GestureDispatcher) Run(ctx context.Context, updates <-chan PanelUpdate) {
+	for {
+		select {
+		case update, ok := <-updates:
+			if !ok {
+				return
+			}
+			d.Dispatch(update)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package nanoleaf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image/color"
+	"time"
+)
+
+const (
+	// flashInterval is how long a flashed panel stays lit, and then dark, each time it flashes
+	flashInterval = 500 * time.Millisecond
+	// restoreTimeout bounds restoring the display after flashing, which is done even if the context has been cancelled
+	restoreTimeout = 10 * time.Second
+)
+
+// The effects reported while a previewed effect, a stream or a colour is shown, which can't be selected by name
+const (
+	dynamicEffect    = "*Dynamic*"
+	extControlEffect = "*ExtControl*"
+	solidEffect      = "*Solid*"
+)
+
+// Identify makes the panels flash, so the controller can be told apart from others
+func (c *Client) Identify(ctx context.Context) error {
+	return c.put(ctx, "identify", struct{}{}, nil)
+}
+
+// FlashPanel flashes a single panel in the specified colour, so it can be located in the physical layout.
+// The panel is flashed using external control mode; the previous effect or colour is restored afterwards.
+// A previewed effect is shown again if the controller still provides it, otherwise the previous colour is restored.
+// ErrNotFound is returned if the panel isn't part of the layout.
+func (c *Client) FlashPanel(ctx context.Context, panelID int, col color.Color, times int) error {
+	if col == nil {
+		return errors.New("colour must be specified")
+	}
+
+	panel, err := c.GetPanel(ctx)
+	if err != nil {
+		return err
+	}
+
+	found := false
+	for _, pos := range panel.Layout.Panels.Panels {
+		if pos.PanelID == panelID {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("%w: panel %d", ErrNotFound, panelID)
+	}
+
+	// A previewed effect isn't installed, so it has to be retrieved now to be shown again afterwards
+	var preview *Effect
+	if panel.Effect.Current == dynamicEffect {
+		if effect, getErr := c.GetEffect(ctx, dynamicEffect); getErr == nil {
+			preview = effect
+		}
+	}
+
+	wasOn := panel.State.On != nil && panel.State.On.Value
+	if !wasOn {
+		if err = c.SetOn(ctx, true); err != nil {
+			return err
+		}
+	}
+
+	err = c.flash(ctx, panel, panelID, col, times)
+
+	// The restore is attempted even if the context has been cancelled, as the panel would otherwise be left in external control mode
+	restoreCtx, cancel := context.WithTimeout(context.Background(), restoreTimeout)
+	defer cancel()
+
+	if restoreErr := c.restoreDisplay(restoreCtx, panel, preview); err == nil {
+		err = restoreErr
+	}
+	if !wasOn {
+		if offErr := c.SetOn(restoreCtx, false); err == nil {
+			err = offErr
+		}
+	}
+	return err
+}
+
+func (c *Client) flash(ctx context.Context, panel *LightPanel, panelID int, col color.Color, times int) error {
+	// The Light Panels support v1 on all firmware versions; everything newer requires v2
+	version := StreamV2
+	if panel.ModelNumber == "NL22" {
+		version = StreamV1
+	}
+
+	streamer, err := c.StartStream(ctx, version)
+	if err != nil {
+		return err
+	}
+	defer streamer.Close()
+
+	rgba := color.RGBAModel.Convert(col).(color.RGBA)
+	lit := []Frame{{PanelID: panelID, Red: rgba.R, Green: rgba.G, Blue: rgba.B, TransitionTime: 1}}
+	dark := []Frame{{PanelID: panelID, TransitionTime: 1}}
+
+	for i := 0; i < times; i++ {
+		for _, frames := range [][]Frame{lit, dark} {
+			if err = streamer.Send(frames); err != nil {
+				return err
+			}
+
+			select {
+			case <-time.After(flashInterval):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+	return nil
+}
+
+// restoreDisplay reselects the effect, or reapplies the colour, the panel was showing.
+// The preview is the effect which was being previewed, if it could be retrieved.
+func (c *Client) restoreDisplay(ctx context.Context, panel *LightPanel, preview *Effect) error {
+	state := panel.State
+	if state.ColorMode == nil || *state.ColorMode == "effect" {
+		switch panel.Effect.Current {
+		case dynamicEffect:
+			if preview != nil {
+				return c.DisplayEffect(ctx, preview, 0)
+			}
+		case extControlEffect, solidEffect:
+			// These can't be selected, so the colour is restored instead
+		default:
+			return c.SetScene(ctx, panel.Effect.Current)
+		}
+	}
+
+	update := NewStateUpdate()
+	if state.Brightness != nil {
+		update = update.Brightness(state.Brightness.Value, 0)
+	}
+	if state.ColorMode != nil && *state.ColorMode == "ct" && state.CT != nil {
+		update = update.CT(state.CT.Value)
+	} else if state.Hue != nil && state.Saturation != nil {
+		update = update.Hue(state.Hue.Value).Saturation(state.Saturation.Value)
+	}
+	return c.UpdateState(ctx, update)
+}
@@ -0,0 +1,107 @@
+package nanoleaf_test
+
+import (
+	"context"
+	"errors"
+	"image/color"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rmrobinson/nanoleaf-go"
+	"github.com/rmrobinson/nanoleaf-go/nanoleaftest"
+)
+
+func TestFlashPanel(t *testing.T) {
+	controller := nanoleaftest.NewController()
+	defer controller.Close()
+	client := controller.Client()
+	ctx := context.Background()
+
+	if err := client.SetOn(ctx, false); err != nil {
+		t.Fatalf("error turning off: %s", err)
+	}
+
+	if err := client.FlashPanel(ctx, 102, color.RGBA{R: 255, A: 0xff}, 1); err != nil {
+		t.Fatalf("error flashing panel: %s", err)
+	}
+
+	expected := []nanoleaf.Frame{
+		{PanelID: 102, Red: 255, TransitionTime: 1},
+		{PanelID: 102, TransitionTime: 1},
+	}
+	if frames := controller.StreamedFrames(); !reflect.DeepEqual(frames, expected) {
+		t.Fatalf("expected frames %v, got %v", expected, frames)
+	}
+
+	panel := controller.Panel()
+	if panel.Effect.Current != "Flames" {
+		t.Fatalf("expected the previous effect to be restored, got %s", panel.Effect.Current)
+	} else if panel.State.On.Value {
+		t.Fatal("expected the panels to be turned off again")
+	}
+}
+
+func TestFlashPanelInvalid(t *testing.T) {
+	controller := nanoleaftest.NewController()
+	defer controller.Close()
+	client := controller.Client()
+	ctx := context.Background()
+
+	if err := client.FlashPanel(ctx, 999, color.White, 1); !errors.Is(err, nanoleaf.ErrNotFound) {
+		t.Fatalf("expected an unknown panel to be rejected, got %v", err)
+	}
+	if err := client.FlashPanel(ctx, 101, nil, 1); err == nil {
+		t.Fatal("expected a nil colour to be rejected")
+	}
+
+	if frames := controller.StreamedFrames(); len(frames) > 0 {
+		t.Fatalf("expected nothing to be streamed, got %v", frames)
+	}
+	if current := controller.Panel().Effect.Current; current != "Flames" {
+		t.Fatalf("expected the effect to be unchanged, got %s", current)
+	}
+}
+
+func TestFlashPanelRestoresColorAfterPreview(t *testing.T) {
+	controller := nanoleaftest.NewController()
+	defer controller.Close()
+	client := controller.Client()
+	ctx := context.Background()
+
+	if err := client.SetHue(ctx, 120); err != nil {
+		t.Fatalf("error setting hue: %s", err)
+	}
+	if err := client.DisplayEffect(ctx, &nanoleaf.Effect{Name: "Preview", AnimationType: "random"}, 0); err != nil {
+		t.Fatalf("error displaying effect: %s", err)
+	}
+	if current := controller.Panel().Effect.Current; current != nanoleaftest.DynamicEffect {
+		t.Fatalf("expected the preview to be displayed, got %s", current)
+	}
+
+	// The preview can't be retrieved from the fake, so the colour is restored instead of selecting it by name
+	if err := client.FlashPanel(ctx, 101, color.White, 1); err != nil {
+		t.Fatalf("error flashing panel: %s", err)
+	}
+
+	panel := controller.Panel()
+	if panel.Effect.Current != nanoleaftest.SolidEffect || *panel.State.ColorMode != "hs" || panel.State.Hue.Value != 120 {
+		t.Fatalf("expected the colour to be restored, got %s in %s mode with hue %d", panel.Effect.Current, *panel.State.ColorMode, panel.State.Hue.Value)
+	}
+}
+
+func TestFlashPanelRestoresWhenCancelled(t *testing.T) {
+	controller := nanoleaftest.NewController()
+	defer controller.Close()
+	client := controller.Client()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	if err := client.FlashPanel(ctx, 101, color.White, 5); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the flashing to stop once the context is done, got %v", err)
+	}
+	if current := controller.Panel().Effect.Current; current != "Flames" {
+		t.Fatalf("expected the previous effect to be restored, got %s", current)
+	}
+}
@@ -0,0 +1,168 @@
+package nanoleaf
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"strings"
+)
+
+// This is the minimal subset of the DNS message format needed to browse for a service over mDNS.
+
+const (
+	dnsTypeA    = 1
+	dnsTypePTR  = 12
+	dnsTypeTXT  = 16
+	dnsTypeAAAA = 28
+	dnsTypeSRV  = 33
+
+	dnsClassIN = 1
+)
+
+var errMalformedDNS = errors.New("malformed dns message")
+
+type dnsRecord struct {
+	Name string
+	Type uint16
+
+	// IP is set for A and AAAA records
+	IP net.IP
+	// Target is set for PTR and SRV records
+	Target string
+	// Port is set for SRV records
+	Port int
+	// Text is set for TXT records
+	Text []string
+}
+
+// newMDNSQuery builds a query for the PTR records of the specified service
+func newMDNSQuery(service string) []byte {
+	// ID and flags are zero; a single question
+	b := []byte{0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0}
+
+	for _, label := range strings.Split(strings.TrimSuffix(service, "."), ".") {
+		b = append(b, byte(len(label)))
+		b = append(b, label...)
+	}
+	b = append(b, 0)
+
+	b = binary.BigEndian.AppendUint16(b, dnsTypePTR)
+	return binary.BigEndian.AppendUint16(b, dnsClassIN)
+}
+
+// parseMDNSResponse returns the records from all sections of the response
+func parseMDNSResponse(b []byte) ([]dnsRecord, error) {
+	if len(b) < 12 {
+		return nil, errMalformedDNS
+	} else if b[2]&0x80 == 0 {
+		// Ignore queries (including our own if it's looped back)
+		return nil, errMalformedDNS
+	}
+
+	questions := int(binary.BigEndian.Uint16(b[4:]))
+	recordCount := int(binary.BigEndian.Uint16(b[6:])) + int(binary.BigEndian.Uint16(b[8:])) + int(binary.BigEndian.Uint16(b[10:]))
+
+	off := 12
+	for i := 0; i < questions; i++ {
+		_, next, err := readDNSName(b, off)
+		if err != nil {
+			return nil, err
+		}
+		off = next + 4
+	}
+
+	var records []dnsRecord
+	for i := 0; i < recordCount; i++ {
+		name, next, err := readDNSName(b, off)
+		if err != nil {
+			return nil, err
+		} else if next+10 > len(b) {
+			return nil, errMalformedDNS
+		}
+
+		r := dnsRecord{
+			Name: name,
+			Type: binary.BigEndian.Uint16(b[next:]),
+		}
+		length := int(binary.BigEndian.Uint16(b[next+8:]))
+		start := next + 10
+		end := start + length
+		if end > len(b) {
+			return nil, errMalformedDNS
+		}
+		data := b[start:end]
+
+		switch r.Type {
+		case dnsTypeA, dnsTypeAAAA:
+			r.IP = net.IP(append([]byte(nil), data...))
+		case dnsTypePTR:
+			if r.Target, _, err = readDNSName(b, start); err != nil {
+				return nil, err
+			}
+		case dnsTypeSRV:
+			if length < 6 {
+				return nil, errMalformedDNS
+			}
+			r.Port = int(binary.BigEndian.Uint16(data[4:]))
+			if r.Target, _, err = readDNSName(b, start+6); err != nil {
+				return nil, err
+			}
+		case dnsTypeTXT:
+			for len(data) > 0 {
+				l := int(data[0])
+				if l+1 > len(data) {
+					return nil, errMalformedDNS
+				}
+				r.Text = append(r.Text, string(data[1:l+1]))
+				data = data[l+1:]
+			}
+		}
+
+		records = append(records, r)
+		off = end
+	}
+
+	return records, nil
+}
+
+// readDNSName decodes the (possibly compressed) name at the offset, returning it and the offset following it
+func readDNSName(b []byte, off int) (string, int, error) {
+	var labels []string
+	next := -1
+
+	// Bound the number of pointers followed to avoid loops
+	for jumps := 0; jumps < 64; {
+		if off >= len(b) {
+			return "", 0, errMalformedDNS
+		}
+
+		l := int(b[off])
+		switch {
+		case l == 0:
+			if next < 0 {
+				next = off + 1
+			}
+			return strings.Join(labels, ".") + ".", next, nil
+		case l&0xC0 == 0xC0:
+			if off+1 >= len(b) {
+				return "", 0, errMalformedDNS
+			}
+			if next < 0 {
+				next = off + 2
+			}
+			off = int(binary.BigEndian.Uint16(b[off:]) & 0x3FFF)
+			jumps++
+		case l&0xC0 != 0:
+			// The other label types are reserved or obsolete
+			return "", 0, errMalformedDNS
+		default:
+			if off+1+l > len(b) {
+				return "", 0, errMalformedDNS
+			}
+			labels = append(labels, string(b[off+1:off+1+l]))
+			off += 1 + l
+		}
+	}
+
+	return "", 0, errMalformedDNS
+}
@@ -0,0 +1,544 @@
+// Package nanoleaftest provides an in-process fake Nanoleaf controller for testing code which uses the nanoleaf package.
+package nanoleaftest
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rmrobinson/nanoleaf-go"
+)
+
+const (
+	// DefaultAPIKey is the API key accepted by a new controller
+	DefaultAPIKey = "nanoleaftest-key"
+
+	// ExtControlEffect is the effect reported as selected while in external control mode
+	ExtControlEffect = "*ExtControl*"
+	// DynamicEffect is the effect reported as selected while an effect is being displayed without being installed
+	DynamicEffect = "*Dynamic*"
+	// SolidEffect is the effect reported as selected once a colour or colour temperature is set
+	SolidEffect = "*Solid*"
+)
+
+// Controller is a fake controller serving the Nanoleaf API over HTTP.
+// The state can be inspected and scripted through its methods while clients use it.
+type Controller struct {
+	server *httptest.Server
+	stream net.PacketConn
+
+	lock        sync.Mutex
+	panel       nanoleaf.LightPanel
+	effects     []nanoleaf.Effect
+	apiKeys     map[string]bool
+	nextKey     int
+	pairingOpen bool
+	latency     time.Duration
+	failures    []failure
+	identifies  int
+	frames      []nanoleaf.Frame
+	version     nanoleaf.StreamVersion
+	subscribers map[*subscriber]bool
+}
+
+type failure struct {
+	method   string
+	endpoint string
+	status   int
+	body     string
+	count    int
+	// applied is set if the request is handled before the failure is returned
+	applied bool
+}
+
+// NewController starts a fake controller with a small layout of Light Panels, a few effects and DefaultAPIKey registered.
+// Close should be called once it is no longer needed.
+func NewController() *Controller {
+	c := &Controller{
+		panel:       defaultPanel(),
+		effects:     defaultEffects(),
+		apiKeys:     map[string]bool{DefaultAPIKey: true},
+		subscribers: map[*subscriber]bool{},
+	}
+
+	// Frames sent in external control mode (v1) are received on this socket
+	c.stream, _ = net.ListenPacket("udp", "127.0.0.1:0")
+	if c.stream != nil {
+		go c.receiveFrames()
+	}
+
+	c.server = httptest.NewServer(http.HandlerFunc(c.serveHTTP))
+	return c
+}
+
+// Close shuts down the controller, ending any open event streams
+func (c *Controller) Close() {
+	c.lock.Lock()
+	for sub := range c.subscribers {
+		sub.close()
+	}
+	c.lock.Unlock()
+
+	c.server.Close()
+	if c.stream != nil {
+		c.stream.Close()
+	}
+}
+
+// URL returns the base URL of the controller, suitable for nanoleaf.WithBaseURL
+func (c *Controller) URL() string {
+	return c.server.URL
+}
+
+// Client creates a client which connects to the controller using DefaultAPIKey.
+// Additional options are applied after the defaults, so can override them.
+func (c *Controller) Client(opts ...nanoleaf.Option) *nanoleaf.Client {
+	defaults := []nanoleaf.Option{
+		nanoleaf.WithBaseURL(c.server.URL),
+		nanoleaf.WithHTTPClient(c.server.Client()),
+		nanoleaf.WithAPIKey(DefaultAPIKey),
+	}
+
+	client, err := nanoleaf.New("", append(defaults, opts...)...)
+	if err != nil {
+		panic("nanoleaftest: creating client: " + err.Error())
+	}
+	return client
+}
+
+// Panel returns the current details of the controller
+func (c *Controller) Panel() nanoleaf.LightPanel {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.panelLocked()
+}
+
+// SetPanel replaces the details of the controller. The effect options are derived from the installed effects.
+func (c *Controller) SetPanel(panel nanoleaf.LightPanel) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.panel = panel
+}
+
+// SetLayout replaces the panel layout, notifying subscribers
+func (c *Controller) SetLayout(layout nanoleaf.PanelLayout) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.panel.Layout = layout
+	c.publishLocked(nanoleaf.EventTypeLayout, attrEvent(1, layout.Panels), attrEvent(2, layout.Orientation))
+}
+
+// Effects returns the installed effects
+func (c *Controller) Effects() []nanoleaf.Effect {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return append([]nanoleaf.Effect(nil), c.effects...)
+}
+
+// SetEffects replaces the installed effects
+func (c *Controller) SetEffects(effects []nanoleaf.Effect) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.effects = append([]nanoleaf.Effect(nil), effects...)
+}
+
+// SetPairing opens or closes the pairing window; new API keys can only be created while it is open
+func (c *Controller) SetPairing(open bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.pairingOpen = open
+}
+
+// APIKeys returns the API keys currently accepted by the controller
+func (c *Controller) APIKeys() []string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var keys []string
+	for key := range c.apiKeys {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// SetLatency delays every response by the specified duration
+func (c *Controller) SetLatency(latency time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.latency = latency
+}
+
+// Fail makes the next count requests with the method to the endpoint fail with the status code.
+// The endpoint is the path following the API key (such as "state" or "effects"), or "new" for creating keys;
+// an empty method or endpoint matches any.
+func (c *Controller) Fail(method string, endpoint string, status int, count int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.failures = append(c.failures, failure{
+		method:   method,
+		endpoint: endpoint,
+		status:   status,
+		body:     http.StatusText(status),
+		count:    count,
+	})
+}
+
+// FailAfterApplying behaves like Fail, except the requests are applied before the failure is returned,
+// as happens when the controller acts on a request but the response is lost. Event streams are not opened.
+func (c *Controller) FailAfterApplying(method string, endpoint string, status int, count int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.failures = append(c.failures, failure{
+		method:   method,
+		endpoint: endpoint,
+		status:   status,
+		body:     http.StatusText(status),
+		count:    count,
+		applied:  true,
+	})
+}
+
+// IdentifyCount returns the number of identify requests received
+func (c *Controller) IdentifyCount() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.identifies
+}
+
+// StreamedFrames returns the frames received in external control mode, in the order they were received
+func (c *Controller) StreamedFrames() []nanoleaf.Frame {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return append([]nanoleaf.Frame(nil), c.frames...)
+}
+
+// SendGestures notifies touch event subscribers of the gestures
+func (c *Controller) SendGestures(gestures ...nanoleaf.Gesture) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	data, _ := json.Marshal(struct {
+		Events []nanoleaf.Gesture `json:"events"`
+	}{gestures})
+	c.publishRawLocked(nanoleaf.EventTypeTouch, data)
+}
+
+func (c *Controller) panelLocked() nanoleaf.LightPanel {
+	panel := c.panel
+	panel.Effect.Options = nil
+	for _, effect := range c.effects {
+		panel.Effect.Options = append(panel.Effect.Options, effect.Name)
+	}
+	return panel
+}
+
+func (c *Controller) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	c.lock.Lock()
+	latency := c.latency
+	c.lock.Unlock()
+
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/api/v1/")
+	if path == r.URL.Path {
+		http.NotFound(w, r)
+		return
+	}
+
+	key, endpoint := path, ""
+	if idx := strings.Index(path, "/"); idx >= 0 {
+		key, endpoint = path[:idx], path[idx+1:]
+	}
+	endpoint = strings.TrimSuffix(endpoint, "/")
+	if key == "new" {
+		endpoint = "new"
+	}
+
+	if f, ok := c.takeFailure(r.Method, endpoint); ok {
+		if f.applied && endpoint != "events" {
+			c.handle(httptest.NewRecorder(), r, key, endpoint)
+		}
+		http.Error(w, f.body, f.status)
+		return
+	}
+
+	c.handle(w, r, key, endpoint)
+}
+
+// handle serves the request to the endpoint once any latency and failures have been applied
+func (c *Controller) handle(w http.ResponseWriter, r *http.Request, key string, endpoint string) {
+	if key == "new" && r.Method == http.MethodPost {
+		c.createKey(w)
+		return
+	}
+
+	c.lock.Lock()
+	authorized := c.apiKeys[key]
+	c.lock.Unlock()
+	if !authorized {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	switch {
+	case endpoint == "" && r.Method == http.MethodGet:
+		c.lock.Lock()
+		panel := c.panelLocked()
+		c.lock.Unlock()
+		writeJSON(w, panel)
+	case endpoint == "" && r.Method == http.MethodDelete:
+		c.lock.Lock()
+		delete(c.apiKeys, key)
+		c.lock.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	case endpoint == "state" && r.Method == http.MethodPut:
+		c.putState(w, r)
+	case endpoint == "effects" && r.Method == http.MethodPut:
+		c.putEffects(w, r)
+	case endpoint == "panelLayout" && r.Method == http.MethodPut:
+		c.putLayout(w, r)
+	case endpoint == "identify" && r.Method == http.MethodPut:
+		c.lock.Lock()
+		c.identifies++
+		c.lock.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	case endpoint == "events" && r.Method == http.MethodGet:
+		c.serveEvents(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (c *Controller) takeFailure(method string, endpoint string) (failure, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for i := range c.failures {
+		f := &c.failures[i]
+		if f.count < 1 || (len(f.method) > 0 && f.method != method) || (len(f.endpoint) > 0 && f.endpoint != endpoint) {
+			continue
+		}
+
+		f.count--
+		return *f, true
+	}
+	return failure{}, false
+}
+
+func (c *Controller) createKey(w http.ResponseWriter) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if !c.pairingOpen {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	c.nextKey++
+	key := "nanoleaftest-key-" + strconv.Itoa(c.nextKey)
+	c.apiKeys[key] = true
+
+	writeJSON(w, map[string]string{"auth_token": key})
+}
+
+type stateChange struct {
+	Value     *json.RawMessage `json:"value"`
+	Increment *int             `json:"increment"`
+	Duration  int              `json:"duration"`
+}
+
+func (c *Controller) putState(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		On         *stateChange `json:"on"`
+		Brightness *stateChange `json:"brightness"`
+		Hue        *stateChange `json:"hue"`
+		Saturation *stateChange `json:"sat"`
+		CT         *stateChange `json:"ct"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	state := &c.panel.State
+	var events []attr
+
+	if req.On != nil && req.On.Value != nil {
+		var on bool
+		if err := json.Unmarshal(*req.On.Value, &on); err != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		}
+		state.On = &nanoleaf.BoolValue{Value: on}
+		events = append(events, attrEvent(1, on))
+	}
+
+	colorMode := ""
+	for _, field := range []struct {
+		change *stateChange
+		value  **nanoleaf.IntRangeValue
+		attr   int
+		mode   string
+	}{
+		{req.Brightness, &state.Brightness, 2, ""},
+		{req.Hue, &state.Hue, 3, "hs"},
+		{req.Saturation, &state.Saturation, 4, "hs"},
+		{req.CT, &state.CT, 5, "ct"},
+	} {
+		if field.change == nil {
+			continue
+		}
+		if *field.value == nil {
+			*field.value = &nanoleaf.IntRangeValue{Max: 100}
+		}
+		current := *field.value
+
+		v := current.Value
+		if field.change.Increment != nil {
+			v += *field.change.Increment
+		} else if field.change.Value != nil {
+			if err := json.Unmarshal(*field.change.Value, &v); err != nil {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				return
+			}
+		} else {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if v < current.Min {
+			v = current.Min
+		} else if current.Max > current.Min && v > current.Max {
+			v = current.Max
+		}
+
+		current.Value = v
+		events = append(events, attrEvent(field.attr, v))
+		if len(field.mode) > 0 {
+			colorMode = field.mode
+		}
+	}
+
+	if len(colorMode) > 0 {
+		state.ColorMode = &colorMode
+		events = append(events, attrEvent(6, colorMode))
+	}
+
+	c.publishLocked(nanoleaf.EventTypeState, events...)
+	// Like a real controller, setting a colour replaces the effect being displayed
+	if len(colorMode) > 0 {
+		c.selectEffectLocked(SolidEffect)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *Controller) putLayout(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Orientation *struct {
+			Value int `json:"value"`
+		} `json:"globalOrientation"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Orientation == nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.panel.Layout.Orientation.Value = req.Orientation.Value
+	c.publishLocked(nanoleaf.EventTypeLayout, attrEvent(2, c.panel.Layout.Orientation))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func defaultPanel() nanoleaf.LightPanel {
+	colorMode := "effect"
+
+	panel := nanoleaf.LightPanel{
+		Name:            "Fake Light Panels",
+		SerialNumber:    "S00000000001",
+		Manufacturer:    "Nanoleaf",
+		FirmwareVersion: "3.0.6",
+		ModelNumber:     "NL22",
+	}
+	panel.State = nanoleaf.PanelState{
+		On:         &nanoleaf.BoolValue{Value: true},
+		Brightness: &nanoleaf.IntRangeValue{Value: 100, Max: 100},
+		Hue:        &nanoleaf.IntRangeValue{Value: 0, Max: 360},
+		Saturation: &nanoleaf.IntRangeValue{Value: 0, Max: 100},
+		CT:         &nanoleaf.IntRangeValue{Value: 4000, Max: 6500, Min: 1200},
+		ColorMode:  &colorMode,
+	}
+	panel.Effect.Current = "Flames"
+	panel.Layout = nanoleaf.PanelLayout{
+		Orientation: nanoleaf.IntRangeValue{Value: 0, Max: 360},
+		Panels: nanoleaf.Layout{
+			PanelCount: 3,
+			SideLength: 150,
+			Panels: []nanoleaf.PanelPosition{
+				{PanelID: 101, X: 0, Y: 0, Orientation: 0, Type: nanoleaf.ShapeTriangle},
+				{PanelID: 102, X: 74, Y: 43, Orientation: 180, Type: nanoleaf.ShapeTriangle},
+				{PanelID: 103, X: 149, Y: 0, Orientation: 0, Type: nanoleaf.ShapeTriangle},
+			},
+		},
+	}
+	return panel
+}
+
+func defaultEffects() []nanoleaf.Effect {
+	return []nanoleaf.Effect{
+		{
+			Name:          "Flames",
+			AnimationType: "highlight",
+			ColorType:     "HSB",
+			Palette: []nanoleaf.HSB{
+				{Hue: 0, Saturation: 100, Brightness: 100},
+				{Hue: 30, Saturation: 100, Brightness: 100},
+			},
+		},
+		{
+			Name:          "Forest",
+			AnimationType: "fade",
+			ColorType:     "HSB",
+			Palette: []nanoleaf.HSB{
+				{Hue: 120, Saturation: 100, Brightness: 60},
+				{Hue: 90, Saturation: 80, Brightness: 40},
+			},
+		},
+		{
+			Name:          "Static Red",
+			AnimationType: "static",
+			AnimationData: "3 101 1 255 0 0 0 1 102 1 255 0 0 0 1 103 1 255 0 0 0 1",
+		},
+	}
+}
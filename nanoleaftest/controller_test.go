@@ -0,0 +1,166 @@
+package nanoleaftest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/rmrobinson/nanoleaf-go"
+)
+
+func TestFail(t *testing.T) {
+	controller := NewController()
+	defer controller.Close()
+	client := controller.Client(nanoleaf.WithRetryPolicy(nanoleaf.RetryPolicy{MaxAttempts: 1}))
+	ctx := context.Background()
+
+	controller.Fail(http.MethodPut, "state", http.StatusServiceUnavailable, 2)
+
+	// Other methods and endpoints are unaffected
+	if _, err := client.GetPanel(ctx); err != nil {
+		t.Fatalf("expected GET to succeed, got %s", err)
+	}
+	if err := client.SetScene(ctx, "Forest"); err != nil {
+		t.Fatalf("expected the effects endpoint to succeed, got %s", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		var apiErr *nanoleaf.APIError
+		if err := client.SetOn(ctx, false); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+			t.Fatalf("expected failure %d to return 503, got %v", i+1, err)
+		}
+	}
+	if !controller.Panel().State.On.Value {
+		t.Fatal("expected failed requests not to be applied")
+	}
+
+	if err := client.SetOn(ctx, false); err != nil {
+		t.Fatalf("expected the request after the failures to succeed, got %s", err)
+	}
+	if controller.Panel().State.On.Value {
+		t.Fatal("expected the successful request to be applied")
+	}
+}
+
+func TestFailMatchesAny(t *testing.T) {
+	controller := NewController()
+	defer controller.Close()
+	client := controller.Client(nanoleaf.WithRetryPolicy(nanoleaf.RetryPolicy{MaxAttempts: 1}))
+	ctx := context.Background()
+
+	controller.Fail("", "", http.StatusNotFound, 2)
+
+	if _, err := client.GetPanel(ctx); !errors.Is(err, nanoleaf.ErrNotFound) {
+		t.Fatalf("expected GET to fail, got %v", err)
+	}
+	if err := client.Identify(ctx); !errors.Is(err, nanoleaf.ErrNotFound) {
+		t.Fatalf("expected identify to fail, got %v", err)
+	}
+	if err := client.Identify(ctx); err != nil {
+		t.Fatalf("expected identify to succeed once the failures are used, got %s", err)
+	}
+	if controller.IdentifyCount() != 1 {
+		t.Fatalf("expected 1 identify, got %d", controller.IdentifyCount())
+	}
+}
+
+func TestFailAfterApplying(t *testing.T) {
+	controller := NewController()
+	defer controller.Close()
+	client := controller.Client(nanoleaf.WithRetryPolicy(nanoleaf.RetryPolicy{MaxAttempts: 1}))
+
+	controller.FailAfterApplying(http.MethodPut, "effects", http.StatusServiceUnavailable, 1)
+
+	if err := client.SetScene(context.Background(), "Forest"); !errors.Is(err, nanoleaf.ErrUnknown) {
+		t.Fatalf("expected the request to fail, got %v", err)
+	}
+	if current := controller.Panel().Effect.Current; current != "Forest" {
+		t.Fatalf("expected the request to be applied, got %s", current)
+	}
+}
+
+func TestColorSelectsSolidEffect(t *testing.T) {
+	controller := NewController()
+	defer controller.Close()
+	client := controller.Client()
+	ctx := context.Background()
+
+	// Brightness applies to the effect, so doesn't replace it
+	if err := client.SetBrightness(ctx, 50, 0); err != nil {
+		t.Fatalf("error setting brightness: %s", err)
+	}
+	if current := controller.Panel().Effect.Current; current != "Flames" {
+		t.Fatalf("expected the effect to be unchanged, got %s", current)
+	}
+
+	if err := client.SetHue(ctx, 120); err != nil {
+		t.Fatalf("error setting hue: %s", err)
+	}
+	panel := controller.Panel()
+	if panel.Effect.Current != SolidEffect || *panel.State.ColorMode != "hs" {
+		t.Fatalf("expected a colour to select %s in hs mode, got %s in %s mode", SolidEffect, panel.Effect.Current, *panel.State.ColorMode)
+	}
+
+	if err := client.SetScene(ctx, "Forest"); err != nil {
+		t.Fatalf("error setting scene: %s", err)
+	}
+	panel = controller.Panel()
+	if panel.Effect.Current != "Forest" || *panel.State.ColorMode != "effect" {
+		t.Fatalf("expected the scene to be selected in effect mode, got %s in %s mode", panel.Effect.Current, *panel.State.ColorMode)
+	}
+}
+
+func TestFailCreateKey(t *testing.T) {
+	controller := NewController()
+	defer controller.Close()
+	controller.SetPairing(true)
+
+	controller.Fail(http.MethodPost, "new", http.StatusForbidden, 1)
+
+	client := controller.Client()
+	if _, err := client.CreateAPIKey(context.Background()); !errors.Is(err, nanoleaf.ErrForbidden) {
+		t.Fatalf("expected creating a key to fail, got %v", err)
+	}
+	if _, err := client.CreateAPIKey(context.Background()); err != nil {
+		t.Fatalf("expected creating a key to succeed, got %s", err)
+	}
+	if keys := controller.APIKeys(); len(keys) != 2 {
+		t.Fatalf("expected 2 keys, got %v", keys)
+	}
+}
+
+func TestSetLatency(t *testing.T) {
+	controller := NewController()
+	defer controller.Close()
+	client := controller.Client(nanoleaf.WithRetryPolicy(nanoleaf.RetryPolicy{MaxAttempts: 1}))
+
+	latency := 100 * time.Millisecond
+	controller.SetLatency(latency)
+
+	start := time.Now()
+	if _, err := client.GetPanel(context.Background()); err != nil {
+		t.Fatalf("error getting panel: %s", err)
+	}
+	if elapsed := time.Since(start); elapsed < latency {
+		t.Fatalf("expected the response to take at least %s, took %s", latency, elapsed)
+	}
+
+	// Requests which give up before the latency has passed fail
+	ctx, cancel := context.WithTimeout(context.Background(), latency/4)
+	defer cancel()
+	if _, err := client.GetPanel(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the deadline to be exceeded, got %v", err)
+	}
+
+	controller.SetLatency(0)
+
+	start = time.Now()
+	if err := client.SetOn(context.Background(), false); err != nil {
+		t.Fatalf("error turning off: %s", err)
+	}
+	if elapsed := time.Since(start); elapsed >= latency {
+		t.Fatalf("expected the latency to be removed, took %s", elapsed)
+	}
+}
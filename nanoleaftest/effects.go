@@ -0,0 +1,192 @@
+package nanoleaftest
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"net"
+	"net/http"
+
+	"github.com/rmrobinson/nanoleaf-go"
+)
+
+type effectCommand struct {
+	Command        string `json:"command"`
+	Duration       int    `json:"duration"`
+	NewName        string `json:"newName"`
+	ControlVersion string `json:"extControlVersion"`
+	nanoleaf.Effect
+}
+
+func (c *Controller) putEffects(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Select *string        `json:"select"`
+		Write  *effectCommand `json:"write"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if req.Select != nil {
+		if c.findEffectLocked(*req.Select) < 0 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		c.selectEffectLocked(*req.Select)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	} else if req.Write == nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	cmd := req.Write
+	idx := c.findEffectLocked(cmd.Name)
+
+	switch cmd.Command {
+	case "request":
+		if idx < 0 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		writeJSON(w, c.effects[idx])
+	case "requestAll":
+		writeJSON(w, map[string][]nanoleaf.Effect{"animations": c.effects})
+	case "add":
+		if len(cmd.Name) < 1 {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		}
+		if idx < 0 {
+			c.effects = append(c.effects, cmd.Effect)
+		} else {
+			c.effects[idx] = cmd.Effect
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case "delete":
+		if idx < 0 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		c.effects = append(c.effects[:idx], c.effects[idx+1:]...)
+		w.WriteHeader(http.StatusNoContent)
+	case "rename":
+		if idx < 0 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		} else if len(cmd.NewName) < 1 {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		}
+		c.effects[idx].Name = cmd.NewName
+		if c.panel.Effect.Current == cmd.Name {
+			c.panel.Effect.Current = cmd.NewName
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case "display", "displayTemp":
+		if cmd.AnimationType != "extControl" {
+			c.selectEffectLocked(DynamicEffect)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		c.selectEffectLocked(ExtControlEffect)
+		if cmd.ControlVersion == string(nanoleaf.StreamV2) {
+			// v2 frames are sent to a fixed port on the controller, which can't be received here
+			c.version = nanoleaf.StreamV2
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		c.version = nanoleaf.StreamV1
+		resp := map[string]interface{}{
+			"streamControlIpAddr":   "127.0.0.1",
+			"streamControlPort":     0,
+			"streamControlProtocol": "udp",
+		}
+		if c.stream != nil {
+			resp["streamControlPort"] = c.stream.LocalAddr().(*net.UDPAddr).Port
+		}
+		writeJSON(w, resp)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func (c *Controller) findEffectLocked(name string) int {
+	for i, effect := range c.effects {
+		if effect.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// selectEffectLocked makes the effect current; any effect other than SolidEffect switches the colour mode to "effect"
+func (c *Controller) selectEffectLocked(name string) {
+	if mode := c.panel.State.ColorMode; name != SolidEffect && (mode == nil || *mode != "effect") {
+		colorMode := "effect"
+		c.panel.State.ColorMode = &colorMode
+		c.publishLocked(nanoleaf.EventTypeState, attrEvent(6, colorMode))
+	}
+
+	c.panel.Effect.Current = name
+	c.publishLocked(nanoleaf.EventTypeEffects, attrEvent(1, name))
+}
+
+func (c *Controller) receiveFrames() {
+	buf := make([]byte, 65536)
+	for {
+		n, _, err := c.stream.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+
+		c.lock.Lock()
+		c.frames = append(c.frames, decodeFrames(c.version, buf[:n])...)
+		c.lock.Unlock()
+	}
+}
+
+// decodeFrames parses a streaming packet, ignoring any malformed trailing data
+func decodeFrames(version nanoleaf.StreamVersion, b []byte) []nanoleaf.Frame {
+	var frames []nanoleaf.Frame
+
+	if version == nanoleaf.StreamV2 {
+		if len(b) < 2 {
+			return nil
+		}
+		count := int(binary.BigEndian.Uint16(b))
+		for i, off := 0, 2; i < count && off+8 <= len(b); i, off = i+1, off+8 {
+			frames = append(frames, nanoleaf.Frame{
+				PanelID:        int(binary.BigEndian.Uint16(b[off:])),
+				Red:            b[off+2],
+				Green:          b[off+3],
+				Blue:           b[off+4],
+				White:          b[off+5],
+				TransitionTime: int(binary.BigEndian.Uint16(b[off+6:])),
+			})
+		}
+		return frames
+	}
+
+	if len(b) < 1 {
+		return nil
+	}
+	count := int(b[0])
+	for i, off := 0, 1; i < count && off+7 <= len(b); i, off = i+1, off+7 {
+		frames = append(frames, nanoleaf.Frame{
+			PanelID:        int(b[off]),
+			Red:            b[off+2],
+			Green:          b[off+3],
+			Blue:           b[off+4],
+			White:          b[off+5],
+			TransitionTime: int(b[off+6]),
+		})
+	}
+	return frames
+}
@@ -0,0 +1,179 @@
+package nanoleaftest
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/rmrobinson/nanoleaf-go"
+)
+
+// subscriberBuffer is the number of events buffered for a subscriber before further events are dropped
+const subscriberBuffer = 64
+
+type attr struct {
+	Attribute int         `json:"attr"`
+	Value     interface{} `json:"value"`
+}
+
+func attrEvent(attribute int, value interface{}) attr {
+	return attr{Attribute: attribute, Value: value}
+}
+
+type subscriber struct {
+	types    map[nanoleaf.EventType]bool
+	messages chan string
+	// touchAddr is where touch data is sent, if the subscriber requested it
+	touchAddr *net.UDPAddr
+
+	done chan struct{}
+	once sync.Once
+}
+
+func (s *subscriber) close() {
+	s.once.Do(func() {
+		close(s.done)
+	})
+}
+
+func (c *Controller) serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	sub := &subscriber{
+		types:    map[nanoleaf.EventType]bool{},
+		messages: make(chan string, subscriberBuffer),
+		done:     make(chan struct{}),
+	}
+	for _, id := range strings.Split(r.URL.Query().Get("id"), ",") {
+		t, err := strconv.Atoi(id)
+		if err != nil || t < 1 || t > 4 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		sub.types[nanoleaf.EventType(t)] = true
+	}
+
+	if port, err := strconv.Atoi(r.Header.Get("TouchEventsPort")); err == nil && sub.types[nanoleaf.EventTypeTouch] {
+		if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+			sub.touchAddr = &net.UDPAddr{IP: net.ParseIP(host), Port: port}
+		}
+	}
+
+	c.lock.Lock()
+	c.subscribers[sub] = true
+	c.lock.Unlock()
+
+	defer func() {
+		c.lock.Lock()
+		delete(c.subscribers, sub)
+		c.lock.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case msg := <-sub.messages:
+			if _, err := w.Write([]byte(msg)); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-sub.done:
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// SendEvent sends raw event data to the subscribers of the event type, such as to test malformed events
+func (c *Controller) SendEvent(eventType nanoleaf.EventType, data string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.publishRawLocked(eventType, []byte(data))
+}
+
+// SendTouches sends the touch data to the subscribers which requested it using the TouchEventsPort header
+func (c *Controller) SendTouches(touches ...nanoleaf.TouchEvent) error {
+	packet := binary.BigEndian.AppendUint16(nil, uint16(len(touches)))
+	for _, touch := range touches {
+		swipedFrom := touch.SwipedFromPanelID
+		if swipedFrom < 0 {
+			swipedFrom = 0xFFFF
+		}
+
+		packet = binary.BigEndian.AppendUint16(packet, uint16(touch.PanelID))
+		packet = append(packet, byte(touch.Type)<<4|byte(touch.Strength&0x0F))
+		packet = binary.BigEndian.AppendUint16(packet, uint16(swipedFrom))
+	}
+
+	c.lock.Lock()
+	var addrs []*net.UDPAddr
+	for sub := range c.subscribers {
+		if sub.touchAddr != nil {
+			addrs = append(addrs, sub.touchAddr)
+		}
+	}
+	c.lock.Unlock()
+
+	for _, addr := range addrs {
+		conn, err := net.DialUDP("udp", nil, addr)
+		if err != nil {
+			return err
+		}
+		_, err = conn.Write(packet)
+		conn.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EndStreams closes all open event streams, as happens when the controller reboots
+func (c *Controller) EndStreams() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for sub := range c.subscribers {
+		sub.close()
+		delete(c.subscribers, sub)
+	}
+}
+
+func (c *Controller) publishLocked(eventType nanoleaf.EventType, attrs ...attr) {
+	if len(attrs) < 1 {
+		return
+	}
+
+	data, _ := json.Marshal(struct {
+		Events []attr `json:"events"`
+	}{attrs})
+	c.publishRawLocked(eventType, data)
+}
+
+func (c *Controller) publishRawLocked(eventType nanoleaf.EventType, data []byte) {
+	msg := "id: " + strconv.Itoa(int(eventType)) + "\ndata: " + string(data) + "\n\n"
+
+	for sub := range c.subscribers {
+		if !sub.types[eventType] {
+			continue
+		}
+
+		select {
+		case sub.messages <- msg:
+		default:
+		}
+	}
+}
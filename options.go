@@ -0,0 +1,162 @@
+package nanoleaf
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Logger is used to report retried requests and reconnections. *log.Logger satisfies this interface.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// Option configures a Client created with New
+type Option func(*options)
+
+type options struct {
+	port        int
+	apiKey      string
+	baseURL     string
+	httpClient  *http.Client
+	transport   http.RoundTripper
+	userAgent   string
+	logger      Logger
+	retryPolicy RetryPolicy
+	timeout     time.Duration
+}
+
+// WithPort sets the port of the panel (defaults to 16021)
+func WithPort(port int) Option {
+	return func(o *options) {
+		o.port = port
+	}
+}
+
+// WithAPIKey sets the API key used to authorize requests
+func WithAPIKey(apiKey string) Option {
+	return func(o *options) {
+		o.apiKey = apiKey
+	}
+}
+
+// WithBaseURL overrides the scheme, host and port derived from the host, such as to reach the panel via a proxy or a test server.
+// Any path is used as a prefix for the API paths.
+func WithBaseURL(baseURL string) Option {
+	return func(o *options) {
+		o.baseURL = baseURL
+	}
+}
+
+// WithHTTPClient sets the HTTP client used to make requests (defaults to a new http.Client)
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(o *options) {
+		o.httpClient = httpClient
+	}
+}
+
+// WithTransport sets the transport used to make requests. It takes precedence over the transport of a client set using WithHTTPClient.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(o *options) {
+		o.transport = transport
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with each request
+func WithUserAgent(userAgent string) Option {
+	return func(o *options) {
+		o.userAgent = userAgent
+	}
+}
+
+// WithLogger sets the logger used to report retried requests and reconnections (defaults to none)
+func WithLogger(logger Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithRetryPolicy sets the retry policy of the client (defaults to DefaultRetryPolicy)
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(o *options) {
+		o.retryPolicy = policy
+	}
+}
+
+// WithTimeout sets the timeout applied to each request attempt when the context has no deadline (defaults to DefaultTimeout)
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.timeout = timeout
+	}
+}
+
+// New creates a new Nanoleaf API client for the panel at the specified host.
+// The host can be a hostname, an IPv4 address or an IPv6 address (optionally bracketed, and with a zone).
+// It can only be empty if WithBaseURL is used.
+func New(host string, opts ...Option) (*Client, error) {
+	o := newOptions(opts)
+	if len(strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")) < 1 && len(o.baseURL) < 1 {
+		return nil, errors.New("host must be specified unless a base URL is set")
+	}
+
+	return newClient(host, o)
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		port:        defaultPort,
+		retryPolicy: DefaultRetryPolicy,
+		timeout:     DefaultTimeout,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+func newClient(host string, o *options) (*Client, error) {
+	hostname := strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	base := &url.URL{
+		Scheme: "http",
+		Host:   net.JoinHostPort(hostname, strconv.Itoa(o.port)),
+	}
+
+	if len(o.baseURL) > 0 {
+		var err error
+		if base, err = url.Parse(o.baseURL); err != nil {
+			return nil, err
+		} else if len(base.Scheme) < 1 || len(base.Host) < 1 {
+			return nil, fmt.Errorf("base URL %q must include a scheme and host", o.baseURL)
+		}
+
+		if len(hostname) < 1 {
+			hostname = base.Hostname()
+		}
+	}
+
+	httpClient := o.httpClient
+	if httpClient == nil {
+		httpClient = &http.Client{}
+	}
+	if o.transport != nil {
+		withTransport := *httpClient
+		withTransport.Transport = o.transport
+		httpClient = &withTransport
+	}
+
+	return &Client{
+		httpClient:  httpClient,
+		baseURL:     strings.TrimSuffix(base.String(), "/"),
+		hostname:    hostname,
+		userAgent:   o.userAgent,
+		logger:      o.logger,
+		retryPolicy: o.retryPolicy,
+		timeout:     o.timeout,
+		apiKey:      o.apiKey,
+	}, nil
+}
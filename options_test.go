@@ -0,0 +1,77 @@
+package nanoleaf
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name     string
+		host     string
+		opts     []Option
+		baseURL  string
+		hostname string
+	}{
+		{"IPv4", "192.168.1.2", nil, "http://192.168.1.2:16021", "192.168.1.2"},
+		{"hostname with port", "nanoleaf.local", []Option{WithPort(1234)}, "http://nanoleaf.local:1234", "nanoleaf.local"},
+		{"IPv6", "fe80::1", nil, "http://[fe80::1]:16021", "fe80::1"},
+		{"bracketed IPv6", "[fe80::1]", nil, "http://[fe80::1]:16021", "fe80::1"},
+		{"IPv6 with zone", "fe80::1%eth0", nil, "http://[fe80::1%25eth0]:16021", "fe80::1%eth0"},
+		{"bracketed IPv6 with zone", "[fe80::1%eth0]", nil, "http://[fe80::1%25eth0]:16021", "fe80::1%eth0"},
+		{"base URL", "", []Option{WithBaseURL("https://proxy:8443/nanoleaf/")}, "https://proxy:8443/nanoleaf", "proxy"},
+		{"base URL with host", "192.168.1.2", []Option{WithBaseURL("http://127.0.0.1:8080")}, "http://127.0.0.1:8080", "192.168.1.2"},
+		{"base URL with IPv6 host", "", []Option{WithBaseURL("http://[::1]:8080")}, "http://[::1]:8080", "::1"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, err := New(test.host, append(test.opts, WithAPIKey("key"))...)
+			if err != nil {
+				t.Fatalf("error creating client: %s", err)
+			}
+
+			if c.baseURL != test.baseURL || c.hostname != test.hostname {
+				t.Fatalf("expected %s (%s), got %s (%s)", test.baseURL, test.hostname, c.baseURL, c.hostname)
+			}
+
+			// The resulting URLs must be valid for requests
+			r, err := c.newRequest(context.Background(), http.MethodGet, c.getURLBase(), nil)
+			if err != nil {
+				t.Fatalf("error creating request: %s", err)
+			}
+			if r.URL.String() != test.baseURL+"/api/v1/key/" {
+				t.Fatalf("unexpected request URL %s", r.URL)
+			}
+		})
+	}
+}
+
+func TestNewInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		host string
+		opts []Option
+	}{
+		{"empty host", "", nil},
+		{"empty brackets", "[]", nil},
+		{"base URL without scheme", "", []Option{WithBaseURL("proxy:8080")}},
+		{"base URL without host", "", []Option{WithBaseURL("http:///nanoleaf")}},
+		{"unparseable base URL", "", []Option{WithBaseURL("http://[::1")}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if c, err := New(test.host, test.opts...); err == nil {
+				t.Fatalf("expected an error, got a client for %s", c.baseURL)
+			}
+		})
+	}
+}
+
+func TestNewClientAllowsEmptyHostname(t *testing.T) {
+	if c := NewClient(&http.Client{}, "", 16021, "key"); c == nil {
+		t.Fatal("expected a client")
+	}
+}
@@ -0,0 +1,53 @@
+package nanoleaf
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const defaultPairInterval = time.Second
+
+// PairOptions configures how Pair waits for the pairing window to open
+type PairOptions struct {
+	// Interval is the time between attempts (defaults to 1 second)
+	Interval time.Duration
+	// Progress, if set, is called after each attempt made while the pairing window is closed.
+	// It can be used to prompt the user to hold the power button.
+	Progress func(attempt int, err error)
+}
+
+// Pair repeatedly attempts to create a new API key until the pairing window is opened, by holding the
+// power button on the controller for 5-7 seconds, or the context is done.
+// Errors other than ErrForbidden (which indicates the pairing window is closed) end the attempt immediately.
+func (c *Client) Pair(ctx context.Context, opts PairOptions) (string, error) {
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = defaultPairInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for attempt := 1; ; attempt++ {
+		key, err := c.CreateAPIKey(ctx)
+		if err == nil {
+			return key, nil
+		} else if !errors.Is(err, ErrForbidden) {
+			if ctx.Err() != nil {
+				return "", ctx.Err()
+			}
+			return "", err
+		}
+
+		if opts.Progress != nil {
+			opts.Progress(attempt, err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+}
@@ -1,6 +1,11 @@
 package nanoleaf
 
-import "context"
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+)
 
 // GetPanel retrieves the panel details
 func (c *Client) GetPanel(ctx context.Context) (*LightPanel, error) {
@@ -33,12 +38,26 @@ func (c *Client) SetOn(ctx context.Context, on bool) error {
 
 // SetScene selects the specified scene name on the panel and applies it
 func (c *Client) SetScene(ctx context.Context, sceneName string) error {
+	return c.selectScene(ctx, sceneName, true)
+}
+
+// selectScene selects the scene, only retrying according to the retry policy if requested.
+// Retries are skipped when the time the scene changes matters more than the change being made.
+func (c *Client) selectScene(ctx context.Context, sceneName string, retry bool) error {
 	var req struct {
 		SceneName string `json:"select"`
 	}
 
 	req.SceneName = sceneName
-	return c.put(ctx, "effects", req, nil)
+	if retry {
+		return c.put(ctx, "effects", req, nil)
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	return c.attempt(ctx, http.MethodPut, c.getURLBase()+"effects", body, nil)
 }
 
 // SetOrientation updates the orientation of the panels (orientation is 360 degrees - from 0 to 359)
@@ -78,7 +97,7 @@ func (c *Client) IncrementBrightness(ctx context.Context, amount int) error {
 
 	req.Brightness.Increment = amount
 
-	return c.put(ctx, "state", req, nil)
+	return c.putNonIdempotent(ctx, "state", req, nil)
 }
 
 // SetHue will set the hue of the light
@@ -104,7 +123,7 @@ func (c *Client) IncrementHue(ctx context.Context, amount int) error {
 
 	req.Hue.Increment = amount
 
-	return c.put(ctx, "state", req, nil)
+	return c.putNonIdempotent(ctx, "state", req, nil)
 }
 
 // SetSaturation will set the saturation of the light
@@ -130,7 +149,7 @@ func (c *Client) IncrementSaturation(ctx context.Context, amount int) error {
 
 	req.Saturation.Increment = amount
 
-	return c.put(ctx, "state", req, nil)
+	return c.putNonIdempotent(ctx, "state", req, nil)
 }
 
 // SetCT will set the colour temperature of the light
@@ -156,7 +175,7 @@ func (c *Client) IncrementCT(ctx context.Context, amount int) error {
 
 	req.ColorTemperature.Increment = amount
 
-	return c.put(ctx, "state", req, nil)
+	return c.putNonIdempotent(ctx, "state", req, nil)
 }
 
 // LightPanel represents the current state of a Nanoleaf Light Panel
@@ -207,6 +226,43 @@ type PanelLayout struct {
 	Panels      Layout        `json:"layout"`
 }
 
+// Rotation is the global orientation of a layout, turning the panel positions counter-clockwise about their centre
+type Rotation struct {
+	// CenterX and CenterY are the average of the panel positions
+	CenterX float64
+	CenterY float64
+	Degrees float64
+}
+
+// Rotation returns the rotation applied to the panel positions by the global orientation
+func (pl PanelLayout) Rotation() Rotation {
+	r := Rotation{Degrees: float64(pl.Orientation.Value)}
+	if len(pl.Panels.Panels) < 1 {
+		return r
+	}
+
+	for _, pos := range pl.Panels.Panels {
+		r.CenterX += float64(pos.X)
+		r.CenterY += float64(pos.Y)
+	}
+	r.CenterX /= float64(len(pl.Panels.Panels))
+	r.CenterY /= float64(len(pl.Panels.Panels))
+	return r
+}
+
+// Apply rotates the point, in the layout coordinate space, about the centre
+func (r Rotation) Apply(x, y float64) (float64, float64) {
+	if r.Degrees == 0 {
+		return x, y
+	}
+
+	rad := r.Degrees * math.Pi / 180
+	sin, cos := math.Sin(rad), math.Cos(rad)
+	dx, dy := x-r.CenterX, y-r.CenterY
+
+	return r.CenterX + dx*cos - dy*sin, r.CenterY + dx*sin + dy*cos
+}
+
 // Layout represents the layout of all the panels making up this light
 type Layout struct {
 	PanelCount int             `json:"numPanels"`
@@ -216,11 +272,11 @@ type Layout struct {
 
 // PanelPosition contains information about the relative layout of a single panel
 type PanelPosition struct {
-	PanelID     int `json:"panelId"`
-	X           int `json:"x"`
-	Y           int `json:"y"`
-	Orientation int `json:"o"`
-	Type        int `json:"shapeType"`
+	PanelID     int       `json:"panelId"`
+	X           int       `json:"x"`
+	Y           int       `json:"y"`
+	Orientation int       `json:"o"`
+	Type        ShapeType `json:"shapeType"`
 }
 
 // Rhythm contains information about the Rhythm module
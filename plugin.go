@@ -0,0 +1,248 @@
+package nanoleaf
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrInvalidOption is returned if a plugin option is outside the range accepted by the plugin
+	ErrInvalidOption = errors.New("invalid option")
+)
+
+// Direction is the direction a linear plugin moves its colours across the panels
+type Direction string
+
+const (
+	// DirectionLeft moves colours from right to left
+	DirectionLeft Direction = "left"
+	// DirectionRight moves colours from left to right
+	DirectionRight Direction = "right"
+	// DirectionUp moves colours from bottom to top
+	DirectionUp Direction = "up"
+	// DirectionDown moves colours from top to bottom
+	DirectionDown Direction = "down"
+)
+
+// WheelOptions configures the wheel plugin, which cycles the palette across the panels in a direction
+type WheelOptions struct {
+	// TransitionTime is in multiples of 100ms, from 1 to 600
+	TransitionTime int
+	Loop           bool
+	// Direction defaults to DirectionRight
+	Direction Direction
+	// ColorsPerFrame is the number of palette colours shown at once, from 2 to 50
+	ColorsPerFrame int
+}
+
+// FlowOptions configures the flow plugin, which flows the palette across the panels in a direction
+type FlowOptions struct {
+	// TransitionTime is in multiples of 100ms, from 1 to 600
+	TransitionTime int
+	// DelayTime is in multiples of 100ms, from 0 to 600
+	DelayTime int
+	Loop      bool
+	// FlowFactor controls how quickly the colours spread, from 1 to 10
+	FlowFactor float64
+	// Direction defaults to DirectionRight
+	Direction Direction
+}
+
+// ExplodeOptions configures the explode plugin, which bursts the palette outwards from the centre
+type ExplodeOptions struct {
+	// TransitionTime is in multiples of 100ms, from 1 to 600
+	TransitionTime int
+	// DelayTime is in multiples of 100ms, from 0 to 600
+	DelayTime int
+	// ExplodeFactor controls how quickly the colours spread, from 0 to 1
+	ExplodeFactor float64
+}
+
+// FadeOptions configures the fade plugin, which fades all the panels through the palette together
+type FadeOptions struct {
+	// TransitionTime is in multiples of 100ms, from 1 to 600
+	TransitionTime int
+	// DelayTime is in multiples of 100ms, from 0 to 600
+	DelayTime int
+	Loop      bool
+}
+
+// RandomOptions configures the random plugin, which sets each panel to a random palette colour
+type RandomOptions struct {
+	// TransitionTime is in multiples of 100ms, from 1 to 600
+	TransitionTime int
+	// DelayTime is in multiples of 100ms, from 0 to 600
+	DelayTime int
+}
+
+// HighlightOptions configures the highlight plugin, which shows the first palette colour with occasional highlights of the others
+type HighlightOptions struct {
+	// TransitionTime is in multiples of 100ms, from 1 to 600
+	TransitionTime int
+	// DelayTime is in multiples of 100ms, from 0 to 600
+	DelayTime int
+	// MainColorProbability is the percentage chance of a panel showing the first palette colour, from 0 to 100
+	MainColorProbability int
+}
+
+// NewWheelEffect creates an effect using the wheel plugin
+func NewWheelEffect(name string, palette []HSB, opts WheelOptions) (*Effect, error) {
+	direction, err := checkDirection(opts.Direction)
+	if err != nil {
+		return nil, err
+	} else if err = checkTransitionTime(opts.TransitionTime); err != nil {
+		return nil, err
+	} else if err = checkIntRange("nColorsPerFrame", opts.ColorsPerFrame, 2, 50); err != nil {
+		return nil, err
+	}
+
+	return newPluginEffect(name, WheelPluginUUID, palette, []PluginOption{
+		{Name: "transTime", Value: opts.TransitionTime},
+		{Name: "loop", Value: opts.Loop},
+		{Name: "linDirection", Value: direction},
+		{Name: "nColorsPerFrame", Value: opts.ColorsPerFrame},
+	})
+}
+
+// NewFlowEffect creates an effect using the flow plugin
+func NewFlowEffect(name string, palette []HSB, opts FlowOptions) (*Effect, error) {
+	direction, err := checkDirection(opts.Direction)
+	if err != nil {
+		return nil, err
+	} else if err = checkTransitionTime(opts.TransitionTime); err != nil {
+		return nil, err
+	} else if err = checkDelayTime(opts.DelayTime); err != nil {
+		return nil, err
+	} else if err = checkFloatRange("flowFactor", opts.FlowFactor, 1, 10); err != nil {
+		return nil, err
+	}
+
+	return newPluginEffect(name, FlowPluginUUID, palette, []PluginOption{
+		{Name: "transTime", Value: opts.TransitionTime},
+		{Name: "delayTime", Value: opts.DelayTime},
+		{Name: "loop", Value: opts.Loop},
+		{Name: "flowFactor", Value: opts.FlowFactor},
+		{Name: "linDirection", Value: direction},
+	})
+}
+
+// NewExplodeEffect creates an effect using the explode plugin
+func NewExplodeEffect(name string, palette []HSB, opts ExplodeOptions) (*Effect, error) {
+	if err := checkTransitionTime(opts.TransitionTime); err != nil {
+		return nil, err
+	} else if err = checkDelayTime(opts.DelayTime); err != nil {
+		return nil, err
+	} else if err = checkFloatRange("explodeFactor", opts.ExplodeFactor, 0, 1); err != nil {
+		return nil, err
+	}
+
+	return newPluginEffect(name, ExplodePluginUUID, palette, []PluginOption{
+		{Name: "transTime", Value: opts.TransitionTime},
+		{Name: "delayTime", Value: opts.DelayTime},
+		{Name: "explodeFactor", Value: opts.ExplodeFactor},
+	})
+}
+
+// NewFadeEffect creates an effect using the fade plugin
+func NewFadeEffect(name string, palette []HSB, opts FadeOptions) (*Effect, error) {
+	if err := checkTransitionTime(opts.TransitionTime); err != nil {
+		return nil, err
+	} else if err = checkDelayTime(opts.DelayTime); err != nil {
+		return nil, err
+	}
+
+	return newPluginEffect(name, FadePluginUUID, palette, []PluginOption{
+		{Name: "transTime", Value: opts.TransitionTime},
+		{Name: "delayTime", Value: opts.DelayTime},
+		{Name: "loop", Value: opts.Loop},
+	})
+}
+
+// NewRandomEffect creates an effect using the random plugin
+func NewRandomEffect(name string, palette []HSB, opts RandomOptions) (*Effect, error) {
+	if err := checkTransitionTime(opts.TransitionTime); err != nil {
+		return nil, err
+	} else if err = checkDelayTime(opts.DelayTime); err != nil {
+		return nil, err
+	}
+
+	return newPluginEffect(name, RandomPluginUUID, palette, []PluginOption{
+		{Name: "transTime", Value: opts.TransitionTime},
+		{Name: "delayTime", Value: opts.DelayTime},
+	})
+}
+
+// NewHighlightEffect creates an effect using the highlight plugin
+func NewHighlightEffect(name string, palette []HSB, opts HighlightOptions) (*Effect, error) {
+	if err := checkTransitionTime(opts.TransitionTime); err != nil {
+		return nil, err
+	} else if err = checkDelayTime(opts.DelayTime); err != nil {
+		return nil, err
+	} else if err = checkIntRange("mainColorProb", opts.MainColorProbability, 0, 100); err != nil {
+		return nil, err
+	}
+
+	return newPluginEffect(name, HighlightPluginUUID, palette, []PluginOption{
+		{Name: "transTime", Value: opts.TransitionTime},
+		{Name: "delayTime", Value: opts.DelayTime},
+		{Name: "mainColorProb", Value: opts.MainColorProbability},
+	})
+}
+
+func newPluginEffect(name string, pluginUUID string, palette []HSB, options []PluginOption) (*Effect, error) {
+	if len(palette) < 1 {
+		return nil, fmt.Errorf("%w: palette must contain at least one colour", ErrInvalidOption)
+	}
+	for _, color := range palette {
+		if err := checkIntRange("hue", color.Hue, 0, 359); err != nil {
+			return nil, err
+		} else if err = checkIntRange("saturation", color.Saturation, 0, 100); err != nil {
+			return nil, err
+		} else if err = checkIntRange("brightness", color.Brightness, 0, 100); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Effect{
+		Name:          name,
+		Version:       "2.0",
+		AnimationType: "plugin",
+		ColorType:     "HSB",
+		PluginType:    "color",
+		PluginUUID:    pluginUUID,
+		PluginOptions: options,
+		Palette:       palette,
+	}, nil
+}
+
+func checkDirection(direction Direction) (Direction, error) {
+	switch direction {
+	case "":
+		return DirectionRight, nil
+	case DirectionLeft, DirectionRight, DirectionUp, DirectionDown:
+		return direction, nil
+	}
+	return "", fmt.Errorf("%w: linDirection %q is not supported", ErrInvalidOption, direction)
+}
+
+func checkTransitionTime(transitionTime int) error {
+	return checkIntRange("transTime", transitionTime, 1, 600)
+}
+
+func checkDelayTime(delayTime int) error {
+	return checkIntRange("delayTime", delayTime, 0, 600)
+}
+
+func checkIntRange(name string, value int, min int, max int) error {
+	if value < min || value > max {
+		return fmt.Errorf("%w: %s must be between %d and %d (got %d)", ErrInvalidOption, name, min, max, value)
+	}
+	return nil
+}
+
+func checkFloatRange(name string, value float64, min float64, max float64) error {
+	if value < min || value > max {
+		return fmt.Errorf("%w: %s must be between %g and %g (got %g)", ErrInvalidOption, name, min, max, value)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package nanoleaf_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/rmrobinson/nanoleaf-go"
+)
+
+var testPalette = []nanoleaf.HSB{{Hue: 0, Saturation: 100, Brightness: 100}, {Hue: 359, Saturation: 0, Brightness: 0}}
+
+func TestPluginEffectValidation(t *testing.T) {
+	wheel := func(opts nanoleaf.WheelOptions) func() (*nanoleaf.Effect, error) {
+		return func() (*nanoleaf.Effect, error) {
+			return nanoleaf.NewWheelEffect("Wheel", testPalette, opts)
+		}
+	}
+	flow := func(opts nanoleaf.FlowOptions) func() (*nanoleaf.Effect, error) {
+		return func() (*nanoleaf.Effect, error) {
+			return nanoleaf.NewFlowEffect("Flow", testPalette, opts)
+		}
+	}
+	explode := func(opts nanoleaf.ExplodeOptions) func() (*nanoleaf.Effect, error) {
+		return func() (*nanoleaf.Effect, error) {
+			return nanoleaf.NewExplodeEffect("Explode", testPalette, opts)
+		}
+	}
+	fade := func(opts nanoleaf.FadeOptions) func() (*nanoleaf.Effect, error) {
+		return func() (*nanoleaf.Effect, error) {
+			return nanoleaf.NewFadeEffect("Fade", testPalette, opts)
+		}
+	}
+	random := func(opts nanoleaf.RandomOptions) func() (*nanoleaf.Effect, error) {
+		return func() (*nanoleaf.Effect, error) {
+			return nanoleaf.NewRandomEffect("Random", testPalette, opts)
+		}
+	}
+	highlight := func(opts nanoleaf.HighlightOptions) func() (*nanoleaf.Effect, error) {
+		return func() (*nanoleaf.Effect, error) {
+			return nanoleaf.NewHighlightEffect("Highlight", testPalette, opts)
+		}
+	}
+	palette := func(palette []nanoleaf.HSB) func() (*nanoleaf.Effect, error) {
+		return func() (*nanoleaf.Effect, error) {
+			return nanoleaf.NewFadeEffect("Fade", palette, nanoleaf.FadeOptions{TransitionTime: 1})
+		}
+	}
+
+	tests := []struct {
+		name  string
+		build func() (*nanoleaf.Effect, error)
+		valid bool
+	}{
+		{"wheel minimums", wheel(nanoleaf.WheelOptions{TransitionTime: 1, ColorsPerFrame: 2}), true},
+		{"wheel maximums", wheel(nanoleaf.WheelOptions{TransitionTime: 600, ColorsPerFrame: 50, Direction: nanoleaf.DirectionDown}), true},
+		{"wheel transition time below", wheel(nanoleaf.WheelOptions{TransitionTime: 0, ColorsPerFrame: 2}), false},
+		{"wheel transition time above", wheel(nanoleaf.WheelOptions{TransitionTime: 601, ColorsPerFrame: 2}), false},
+		{"wheel colours per frame below", wheel(nanoleaf.WheelOptions{TransitionTime: 1, ColorsPerFrame: 1}), false},
+		{"wheel colours per frame above", wheel(nanoleaf.WheelOptions{TransitionTime: 1, ColorsPerFrame: 51}), false},
+		{"wheel unknown direction", wheel(nanoleaf.WheelOptions{TransitionTime: 1, ColorsPerFrame: 2, Direction: "sideways"}), false},
+
+		{"flow minimums", flow(nanoleaf.FlowOptions{TransitionTime: 1, DelayTime: 0, FlowFactor: 1, Direction: nanoleaf.DirectionLeft}), true},
+		{"flow maximums", flow(nanoleaf.FlowOptions{TransitionTime: 600, DelayTime: 600, FlowFactor: 10, Direction: nanoleaf.DirectionUp}), true},
+		{"flow delay time below", flow(nanoleaf.FlowOptions{TransitionTime: 1, DelayTime: -1, FlowFactor: 1}), false},
+		{"flow delay time above", flow(nanoleaf.FlowOptions{TransitionTime: 1, DelayTime: 601, FlowFactor: 1}), false},
+		{"flow factor below", flow(nanoleaf.FlowOptions{TransitionTime: 1, FlowFactor: 0.99}), false},
+		{"flow factor above", flow(nanoleaf.FlowOptions{TransitionTime: 1, FlowFactor: 10.01}), false},
+		{"flow unknown direction", flow(nanoleaf.FlowOptions{TransitionTime: 1, FlowFactor: 1, Direction: "Left"}), false},
+
+		{"explode minimums", explode(nanoleaf.ExplodeOptions{TransitionTime: 1, DelayTime: 0, ExplodeFactor: 0}), true},
+		{"explode maximums", explode(nanoleaf.ExplodeOptions{TransitionTime: 600, DelayTime: 600, ExplodeFactor: 1}), true},
+		{"explode factor below", explode(nanoleaf.ExplodeOptions{TransitionTime: 1, ExplodeFactor: -0.01}), false},
+		{"explode factor above", explode(nanoleaf.ExplodeOptions{TransitionTime: 1, ExplodeFactor: 1.01}), false},
+
+		{"fade maximums", fade(nanoleaf.FadeOptions{TransitionTime: 600, DelayTime: 600, Loop: true}), true},
+		{"fade transition time below", fade(nanoleaf.FadeOptions{TransitionTime: 0}), false},
+		{"fade delay time above", fade(nanoleaf.FadeOptions{TransitionTime: 1, DelayTime: 601}), false},
+
+		{"random minimums", random(nanoleaf.RandomOptions{TransitionTime: 1}), true},
+		{"random transition time above", random(nanoleaf.RandomOptions{TransitionTime: 601}), false},
+		{"random delay time below", random(nanoleaf.RandomOptions{TransitionTime: 1, DelayTime: -1}), false},
+
+		{"highlight minimums", highlight(nanoleaf.HighlightOptions{TransitionTime: 1, MainColorProbability: 0}), true},
+		{"highlight maximums", highlight(nanoleaf.HighlightOptions{TransitionTime: 600, DelayTime: 600, MainColorProbability: 100}), true},
+		{"highlight probability below", highlight(nanoleaf.HighlightOptions{TransitionTime: 1, MainColorProbability: -1}), false},
+		{"highlight probability above", highlight(nanoleaf.HighlightOptions{TransitionTime: 1, MainColorProbability: 101}), false},
+
+		{"empty palette", palette(nil), false},
+		{"hue above", palette([]nanoleaf.HSB{{Hue: 360}}), false},
+		{"hue below", palette([]nanoleaf.HSB{{Hue: -1}}), false},
+		{"saturation above", palette([]nanoleaf.HSB{{Saturation: 101}}), false},
+		{"brightness below", palette([]nanoleaf.HSB{{Brightness: -1}}), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			effect, err := test.build()
+			if test.valid {
+				if err != nil {
+					t.Fatalf("expected no error, got %s", err)
+				} else if effect == nil {
+					t.Fatal("expected an effect")
+				}
+			} else if !errors.Is(err, nanoleaf.ErrInvalidOption) {
+				t.Fatalf("expected ErrInvalidOption, got %v", err)
+			}
+		})
+	}
+}
+
+func TestNewWheelEffect(t *testing.T) {
+	effect, err := nanoleaf.NewWheelEffect("Wheel", testPalette, nanoleaf.WheelOptions{TransitionTime: 20, ColorsPerFrame: 3})
+	if err != nil {
+		t.Fatalf("error creating effect: %s", err)
+	}
+
+	if effect.Name != "Wheel" || effect.PluginUUID != nanoleaf.WheelPluginUUID || effect.AnimationType != "plugin" || len(effect.Palette) != 2 {
+		t.Fatalf("unexpected effect %+v", effect)
+	}
+
+	options := map[string]interface{}{}
+	for _, option := range effect.PluginOptions {
+		options[option.Name] = option.Value
+	}
+	// The direction defaults to right
+	if options["linDirection"] != nanoleaf.DirectionRight || options["transTime"] != 20 || options["nColorsPerFrame"] != 3 || options["loop"] != false {
+		t.Fatalf("unexpected plugin options %v", options)
+	}
+}
@@ -0,0 +1,217 @@
+package nanoleaf
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"time"
+)
+
+const (
+	defaultMinBackoff  = time.Second
+	defaultMaxBackoff  = time.Minute
+	defaultIdleTimeout = time.Minute
+)
+
+// ReconnectOptions configures how a reconnecting subscription recovers from a lost event stream.
+// Zero values are replaced with sensible defaults.
+type ReconnectOptions struct {
+	// MinBackoff is the delay before the first reconnection attempt (defaults to 1 second)
+	MinBackoff time.Duration
+	// MaxBackoff caps the delay between reconnection attempts (defaults to 1 minute)
+	MaxBackoff time.Duration
+	// IdleTimeout is how long the stream can be silent before the panel is probed to check it is still reachable.
+	// The stream is reconnected if the probe fails, as the connection can't otherwise be told apart from one
+	// the panel has dropped without closing (defaults to 1 minute)
+	IdleTimeout time.Duration
+}
+
+func (o ReconnectOptions) withDefaults() ReconnectOptions {
+	if o.MinBackoff <= 0 {
+		o.MinBackoff = defaultMinBackoff
+	}
+	if o.MaxBackoff < o.MinBackoff {
+		o.MaxBackoff = defaultMaxBackoff
+		if o.MaxBackoff < o.MinBackoff {
+			o.MaxBackoff = o.MinBackoff
+		}
+	}
+	if o.IdleTimeout <= 0 {
+		o.IdleTimeout = defaultIdleTimeout
+	}
+	return o
+}
+
+// SubscribeWithReconnect behaves like Subscribe but keeps the event stream open until the context is cancelled.
+// If the stream is dropped, or stalls and the panel can't be reached, it is reopened using jittered exponential backoff.
+// The backoff is only reset once a reopened stream delivers an event or stays open for MaxBackoff.
+// After each reconnection the full panel state is retrieved and delivered as synthetic updates
+// (with Synthetic set) so that changes made while disconnected are not missed.
+// The channel is closed only when the context is cancelled.
+func (c *Client) SubscribeWithReconnect(ctx context.Context, opts ReconnectOptions, types ...EventType) <-chan PanelUpdate {
+	return c.subscribeWithReconnect(ctx, opts, false, types...)
+}
+
+// subscribeWithReconnect implements SubscribeWithReconnect, optionally also delivering the synthetic updates
+// once the stream is first opened for callers which retrieved the state before the stream was open.
+func (c *Client) subscribeWithReconnect(ctx context.Context, opts ReconnectOptions, resyncFirst bool, types ...EventType) <-chan PanelUpdate {
+	opts = opts.withDefaults()
+	updates := make(chan PanelUpdate)
+
+	send := func(update PanelUpdate) bool {
+		select {
+		case updates <- update:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	go func() {
+		defer close(updates)
+
+		connected := false
+		attempt := 0
+
+		for {
+			streamCtx, cancel := context.WithCancel(ctx)
+
+			resp, err := c.openEvents(streamCtx, types, nil)
+			if err == nil {
+				resync := connected || resyncFirst
+				connected = true
+				opened := time.Now()
+
+				if resync {
+					// The stream is open before the state is retrieved so nothing can be missed in between
+					if panel, err := c.GetPanel(streamCtx); err == nil {
+						for _, update := range syntheticUpdates(panel, types) {
+							if !send(update) {
+								break
+							}
+						}
+					}
+				}
+
+				activity := make(chan struct{}, 1)
+				go c.watchStream(streamCtx, cancel, opts.IdleTimeout, activity)
+
+				received := false
+				readEvents(&activityReader{r: resp.Body, activity: activity}, func(update PanelUpdate) bool {
+					received = true
+					return send(update)
+				})
+				resp.Body.Close()
+
+				// Only a stream which delivered events or stayed open resets the backoff,
+				// so a controller which drops every connection straight away isn't hammered
+				if received || time.Since(opened) >= opts.MaxBackoff {
+					attempt = 0
+				}
+			}
+			cancel()
+
+			if ctx.Err() != nil {
+				return
+			}
+
+			delay := backoff(attempt, opts.MinBackoff, opts.MaxBackoff)
+			if err != nil {
+				c.logf("nanoleaf: error opening event stream, retrying in %s: %s", delay, err.Error())
+			} else {
+				c.logf("nanoleaf: event stream closed, reconnecting in %s", delay)
+			}
+
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return
+			}
+			attempt++
+		}
+	}()
+
+	return updates
+}
+
+// watchStream probes the panel each time the stream has been idle for the specified timeout,
+// cancelling the stream if the panel can't be reached.
+func (c *Client) watchStream(ctx context.Context, cancel context.CancelFunc, timeout time.Duration, activity <-chan struct{}) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-activity:
+		case <-timer.C:
+			probeCtx, probeCancel := context.WithTimeout(ctx, timeout)
+			_, err := c.GetPanel(probeCtx)
+			probeCancel()
+
+			if err != nil {
+				cancel()
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+
+		timer.Reset(timeout)
+	}
+}
+
+// activityReader signals each time data is read from the stream, including the keep-alive comments
+// which don't result in an update.
+type activityReader struct {
+	r        io.Reader
+	activity chan<- struct{}
+}
+
+func (ar *activityReader) Read(p []byte) (int, error) {
+	n, err := ar.r.Read(p)
+	if n > 0 {
+		select {
+		case ar.activity <- struct{}{}:
+		default:
+		}
+	}
+	return n, err
+}
+
+// syntheticUpdates converts the full panel state into the updates of the requested types.
+func syntheticUpdates(panel *LightPanel, types []EventType) []PanelUpdate {
+	if len(types) < 1 {
+		types = []EventType{EventTypeState, EventTypeLayout, EventTypeEffects}
+	}
+
+	var updates []PanelUpdate
+	for _, t := range types {
+		switch t {
+		case EventTypeState:
+			state := panel.State
+			updates = append(updates, PanelUpdate{TypeID: int(t), State: &state, Synthetic: true})
+		case EventTypeLayout:
+			layout := panel.Layout
+			updates = append(updates, PanelUpdate{TypeID: int(t), Layout: &layout, Synthetic: true})
+		case EventTypeEffects:
+			effect := panel.Effect
+			updates = append(updates, PanelUpdate{TypeID: int(t), Effect: &effect, Synthetic: true})
+		}
+	}
+	return updates
+}
+
+// backoff returns the delay before the specified (zero-based) attempt.
+// The delay doubles each attempt up to the maximum, with up to half of it randomized to avoid synchronized retries.
+func backoff(attempt int, min time.Duration, max time.Duration) time.Duration {
+	d := min
+	for i := 0; i < attempt && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
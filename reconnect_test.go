@@ -0,0 +1,204 @@
+package nanoleaf_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rmrobinson/nanoleaf-go"
+	"github.com/rmrobinson/nanoleaf-go/nanoleaftest"
+)
+
+// nextUpdate returns the next update from the channel, failing the test if none arrives in time
+func nextUpdate(t *testing.T, updates <-chan nanoleaf.PanelUpdate) nanoleaf.PanelUpdate {
+	t.Helper()
+
+	select {
+	case update, ok := <-updates:
+		if !ok {
+			t.Fatal("update channel closed")
+		}
+		return update
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for update")
+	}
+	return nanoleaf.PanelUpdate{}
+}
+
+func TestSubscribeWithReconnectResyncsAfterStreamEnds(t *testing.T) {
+	controller := nanoleaftest.NewController()
+	defer controller.Close()
+	client := controller.Client()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	opts := nanoleaf.ReconnectOptions{MinBackoff: 200 * time.Millisecond, MaxBackoff: 200 * time.Millisecond}
+	updates := client.SubscribeWithReconnect(ctx, opts, nanoleaf.EventTypeState)
+
+	// Keep changing the state until the first event shows the stream is open
+	waitForStream(t, client, updates)
+
+	controller.EndStreams()
+	// Made while disconnected, so it can only be seen through the resync
+	if err := client.SetBrightness(ctx, 42, 0); err != nil {
+		t.Fatalf("error setting brightness: %s", err)
+	}
+
+	update := nextUpdate(t, updates)
+	if !update.Synthetic {
+		t.Fatalf("expected a synthetic update after reconnecting, got %+v", update)
+	}
+	if update.State == nil || update.State.Brightness == nil || update.State.Brightness.Value != 42 {
+		t.Fatalf("expected the resync to include the brightness change, got %+v", update.State)
+	}
+
+	// The reopened stream delivers events again
+	if err := client.SetOn(ctx, false); err != nil {
+		t.Fatalf("error turning off: %s", err)
+	}
+	update = nextUpdate(t, updates)
+	if update.Synthetic || update.State == nil || update.State.On == nil || update.State.On.Value {
+		t.Fatalf("expected an off event from the reopened stream, got %+v", update)
+	}
+}
+
+func TestSubscribeWithReconnectKeepsIdleStream(t *testing.T) {
+	controller := nanoleaftest.NewController()
+	defer controller.Close()
+	client := controller.Client()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	opts := nanoleaf.ReconnectOptions{MinBackoff: 10 * time.Millisecond, MaxBackoff: 10 * time.Millisecond, IdleTimeout: 100 * time.Millisecond}
+	updates := client.SubscribeWithReconnect(ctx, opts, nanoleaf.EventTypeState)
+	waitForStream(t, client, updates)
+
+	// The stream is silent for several idle timeouts but the panel is reachable, so it is kept open
+	time.Sleep(5 * opts.IdleTimeout)
+
+	if err := client.SetOn(ctx, false); err != nil {
+		t.Fatalf("error turning off: %s", err)
+	}
+	update := nextUpdate(t, updates)
+	if update.Synthetic || update.State == nil || update.State.On == nil || update.State.On.Value {
+		t.Fatalf("expected the off event without a resync, got %+v", update)
+	}
+}
+
+func TestSubscribeWithReconnectReopensUnreachableStream(t *testing.T) {
+	controller := nanoleaftest.NewController()
+	defer controller.Close()
+	client := controller.Client(nanoleaf.WithRetryPolicy(nanoleaf.RetryPolicy{MaxAttempts: 1}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	opts := nanoleaf.ReconnectOptions{MinBackoff: 10 * time.Millisecond, MaxBackoff: 10 * time.Millisecond, IdleTimeout: 100 * time.Millisecond}
+	updates := client.SubscribeWithReconnect(ctx, opts, nanoleaf.EventTypeState)
+	waitForStream(t, client, updates)
+
+	// The probe made once the stream goes idle fails, so the stream is reopened and resynced
+	controller.Fail(http.MethodGet, "", http.StatusServiceUnavailable, 1)
+
+	update := nextUpdate(t, updates)
+	if !update.Synthetic || update.State == nil {
+		t.Fatalf("expected a synthetic state update after the probe failed, got %+v", update)
+	}
+}
+
+func TestSubscribeWithReconnectBacksOffShortLivedStreams(t *testing.T) {
+	var (
+		lock  sync.Mutex
+		opens []time.Time
+	)
+	// Accepts every stream then drops it straight away
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/events") {
+			http.NotFound(w, r)
+			return
+		}
+
+		lock.Lock()
+		opens = append(opens, time.Now())
+		lock.Unlock()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client, err := nanoleaf.New("", nanoleaf.WithBaseURL(server.URL), nanoleaf.WithAPIKey("key"))
+	if err != nil {
+		t.Fatalf("error creating client: %s", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	opts := nanoleaf.ReconnectOptions{MinBackoff: 20 * time.Millisecond, MaxBackoff: time.Second}
+	updates := client.SubscribeWithReconnect(ctx, opts)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		lock.Lock()
+		count := len(opens)
+		lock.Unlock()
+
+		if count >= 6 {
+			break
+		} else if time.Now().After(deadline) {
+			t.Fatalf("expected 6 connections, got %d", count)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	for range updates {
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	// The fifth reconnection waits at least half of 16 times the minimum backoff
+	if gap := opens[5].Sub(opens[4]); gap < 8*opts.MinBackoff {
+		t.Fatalf("expected the backoff to grow, waited %s before the last reconnection", gap)
+	}
+}
+
+func TestSubscribeWithReconnectClosesOnCancel(t *testing.T) {
+	controller := nanoleaftest.NewController()
+	defer controller.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	updates := controller.Client().SubscribeWithReconnect(ctx, nanoleaf.ReconnectOptions{})
+	cancel()
+
+	select {
+	case _, ok := <-updates:
+		if ok {
+			t.Fatal("expected no updates after cancelling")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("channel not closed after cancelling")
+	}
+}
+
+func waitForStream(t *testing.T, client *nanoleaf.Client, updates <-chan nanoleaf.PanelUpdate) {
+	t.Helper()
+
+	for i := 0; i < 10; i++ {
+		if err := client.SetHue(context.Background(), i); err != nil {
+			t.Fatalf("error setting hue: %s", err)
+		}
+
+		select {
+		case <-updates:
+			return
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+	t.Fatal("event stream never opened")
+}
@@ -0,0 +1,196 @@
+package replay
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Recorder is an http.RoundTripper which records the requests it sends, and the responses received, into a cassette.
+// Streamed responses are recorded as they are read by the client.
+type Recorder struct {
+	transport http.RoundTripper
+
+	lock         sync.Mutex
+	interactions []*Interaction
+	// keys holds the API keys seen so far, which are scrubbed from bodies
+	keys map[string]bool
+}
+
+// NewRecorder creates a recorder which sends requests using the transport (or http.DefaultTransport if nil)
+func NewRecorder(transport http.RoundTripper) *Recorder {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	return &Recorder{
+		transport: transport,
+		keys:      map[string]bool{},
+	}
+}
+
+// RoundTrip sends the request and records the interaction
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		if reqBody, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	path, key := requestPath(req)
+	interaction := &Interaction{
+		Method: req.Method,
+		Path:   path,
+	}
+
+	r.lock.Lock()
+	if len(key) > 0 {
+		r.keys[key] = true
+	}
+	interaction.RequestBody = r.scrubLocked(string(reqBody))
+	r.lock.Unlock()
+
+	start := time.Now()
+	resp, err := r.transport.RoundTrip(req)
+	interaction.Latency = time.Since(start)
+
+	if err != nil {
+		r.lock.Lock()
+		interaction.Error = r.scrubLocked(err.Error())
+		r.interactions = append(r.interactions, interaction)
+		r.lock.Unlock()
+		return nil, err
+	}
+
+	interaction.StatusCode = resp.StatusCode
+
+	if isStream(resp.Header) {
+		r.lock.Lock()
+		interaction.Header = r.scrubHeaderLocked(resp.Header)
+		r.interactions = append(r.interactions, interaction)
+		r.lock.Unlock()
+
+		resp.Body = &recordingBody{
+			ReadCloser:  resp.Body,
+			recorder:    r,
+			interaction: interaction,
+			start:       time.Now(),
+		}
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	r.lock.Lock()
+	r.learnKeyLocked(body)
+	interaction.Header = r.scrubHeaderLocked(resp.Header)
+	interaction.Body = r.scrubLocked(string(body))
+	r.interactions = append(r.interactions, interaction)
+	r.lock.Unlock()
+
+	return resp, nil
+}
+
+// Cassette returns the interactions recorded so far
+func (r *Recorder) Cassette() *Cassette {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	cassette := &Cassette{}
+	for _, interaction := range r.interactions {
+		i := *interaction
+		i.Header = interaction.Header.Clone()
+		i.Chunks = append([]Chunk(nil), interaction.Chunks...)
+		cassette.Interactions = append(cassette.Interactions, i)
+	}
+	return cassette
+}
+
+// Save writes the interactions recorded so far to the specified file
+func (r *Recorder) Save(path string) error {
+	return r.Cassette().Save(path)
+}
+
+// learnKeyLocked records the API key returned when one is created, so it is scrubbed from subsequent interactions
+func (r *Recorder) learnKeyLocked(body []byte) {
+	var created struct {
+		AuthToken string `json:"auth_token"`
+	}
+	if err := json.Unmarshal(body, &created); err == nil && len(created.AuthToken) > 0 {
+		r.keys[created.AuthToken] = true
+	}
+}
+
+func (r *Recorder) scrubLocked(s string) string {
+	var keys []string
+	for key := range r.keys {
+		keys = append(keys, key)
+	}
+	// Longer keys go first so a key which contains another is scrubbed entirely
+	sort.Slice(keys, func(i, j int) bool {
+		return len(keys[i]) > len(keys[j])
+	})
+
+	for _, key := range keys {
+		s = strings.ReplaceAll(s, key, ScrubbedKey)
+	}
+	return s
+}
+
+// scrubHeaderLocked returns a copy of the response header with the API keys scrubbed from the values
+func (r *Recorder) scrubHeaderLocked(header http.Header) http.Header {
+	scrubbed := http.Header{}
+	for name, values := range header {
+		// These vary between recordings, and the length no longer holds once the body is scrubbed
+		if name == "Date" || name == "Content-Length" {
+			continue
+		}
+
+		for _, value := range values {
+			scrubbed.Add(name, r.scrubLocked(value))
+		}
+	}
+	return scrubbed
+}
+
+type recordingBody struct {
+	io.ReadCloser
+	recorder    *Recorder
+	interaction *Interaction
+	start       time.Time
+}
+
+func (b *recordingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+
+	if n > 0 || err == io.EOF {
+		b.recorder.lock.Lock()
+		if n > 0 {
+			b.interaction.Chunks = append(b.interaction.Chunks, Chunk{
+				Offset: time.Since(b.start),
+				Data:   b.recorder.scrubLocked(string(p[:n])),
+			})
+		}
+		if err == io.EOF {
+			b.interaction.Ended = true
+		}
+		b.recorder.lock.Unlock()
+	}
+	return n, err
+}
@@ -0,0 +1,87 @@
+// Package replay records the HTTP traffic between a nanoleaf.Client and a controller, and replays it later.
+// This allows code to be tested against the behaviour of specific devices and firmware versions without the hardware.
+//
+// Recordings are scrubbed of API keys, including in paths, bodies and response headers, so can be checked in alongside tests.
+package replay
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rmrobinson/nanoleaf-go"
+)
+
+// ScrubbedKey replaces any API key in a recording
+const ScrubbedKey = "REDACTED"
+
+// Cassette is a recording of the interactions with a controller, in the order they were made
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is a single request and the response to it
+type Interaction struct {
+	Method string `json:"method"`
+	// Path is the request path and query, with any API key scrubbed
+	Path        string `json:"path"`
+	RequestBody string `json:"requestBody,omitempty"`
+
+	// Error is set if no response was received
+	Error      string      `json:"error,omitempty"`
+	StatusCode int         `json:"statusCode,omitempty"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	// Latency is the time taken to receive the response headers
+	Latency time.Duration `json:"latency,omitempty"`
+
+	// Chunks holds the body of streamed (event stream) responses as it was received
+	Chunks []Chunk `json:"chunks,omitempty"`
+	// Ended is set if the controller ended a streamed response, rather than the client closing it
+	Ended bool `json:"ended,omitempty"`
+}
+
+// Chunk is a part of a streamed response
+type Chunk struct {
+	// Offset is the time the chunk was received, relative to the response headers
+	Offset time.Duration `json:"offset"`
+	Data   string        `json:"data"`
+}
+
+// Load reads a cassette from the specified file
+func Load(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cassette Cassette
+	if err = json.Unmarshal(data, &cassette); err != nil {
+		return nil, err
+	}
+	return &cassette, nil
+}
+
+// Save writes the cassette to the specified file
+func (c *Cassette) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// requestPath returns the scrubbed path and query of the request, along with the API key it contained
+func requestPath(r *http.Request) (string, string) {
+	path, key := nanoleaf.ReplaceAPIKey(r.URL.Path, ScrubbedKey)
+	if len(r.URL.RawQuery) > 0 {
+		path += "?" + r.URL.RawQuery
+	}
+	return path, key
+}
+
+func isStream(header http.Header) bool {
+	return strings.HasPrefix(header.Get("Content-Type"), "text/event-stream")
+}
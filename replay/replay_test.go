@@ -0,0 +1,208 @@
+package replay_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rmrobinson/nanoleaf-go"
+	"github.com/rmrobinson/nanoleaf-go/nanoleaftest"
+	"github.com/rmrobinson/nanoleaf-go/replay"
+)
+
+var noRetries = nanoleaf.WithRetryPolicy(nanoleaf.RetryPolicy{MaxAttempts: 1})
+
+// replayClient creates a client served by the cassette, using a different key to the one recorded
+func replayClient(t *testing.T, replayer *replay.Replayer) *nanoleaf.Client {
+	t.Helper()
+
+	client, err := nanoleaf.New("", nanoleaf.WithBaseURL("http://replay.invalid"), nanoleaf.WithTransport(replayer),
+		nanoleaf.WithAPIKey("replayed-key"), noRetries)
+	if err != nil {
+		t.Fatalf("error creating client: %s", err)
+	}
+	return client
+}
+
+func TestRecordReplayRoundTrip(t *testing.T) {
+	controller := nanoleaftest.NewController()
+	defer controller.Close()
+	ctx := context.Background()
+
+	recorder := replay.NewRecorder(nil)
+	client := controller.Client(nanoleaf.WithTransport(recorder), noRetries)
+
+	recorded, err := client.GetPanel(ctx)
+	if err != nil {
+		t.Fatalf("error getting panel: %s", err)
+	}
+	if err = client.SetOn(ctx, false); err != nil {
+		t.Fatalf("error turning off: %s", err)
+	}
+
+	controller.SetPairing(true)
+	newKey, err := client.CreateAPIKey(ctx)
+	if err != nil {
+		t.Fatalf("error creating key: %s", err)
+	}
+	if err = client.DeleteAPIKey(ctx, newKey); err != nil {
+		t.Fatalf("error deleting key: %s", err)
+	}
+
+	wrongKey := controller.Client(nanoleaf.WithTransport(recorder), nanoleaf.WithAPIKey("wrong-key"), noRetries)
+	if _, err = wrongKey.GetPanel(ctx); !errors.Is(err, nanoleaf.ErrUnauthorized) {
+		t.Fatalf("expected an unauthorized error, got %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	if err = recorder.Save(path); err != nil {
+		t.Fatalf("error saving cassette: %s", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("error reading cassette: %s", err)
+	}
+	for _, key := range []string{nanoleaftest.DefaultAPIKey, newKey, "wrong-key"} {
+		if bytes.Contains(data, []byte(key)) {
+			t.Errorf("expected key %s to be scrubbed from the cassette", key)
+		}
+	}
+
+	cassette, err := replay.Load(path)
+	if err != nil {
+		t.Fatalf("error loading cassette: %s", err)
+	}
+	replayer := replay.NewReplayer(cassette)
+	client = replayClient(t, replayer)
+
+	replayed, err := client.GetPanel(ctx)
+	if err != nil {
+		t.Fatalf("error replaying panel: %s", err)
+	} else if !reflect.DeepEqual(replayed, recorded) {
+		t.Fatalf("expected panel %+v, got %+v", recorded, replayed)
+	}
+	if err = client.SetOn(ctx, false); err != nil {
+		t.Fatalf("error replaying turning off: %s", err)
+	}
+	replayedKey, err := client.CreateAPIKey(ctx)
+	if err != nil {
+		t.Fatalf("error replaying key creation: %s", err)
+	} else if replayedKey != replay.ScrubbedKey {
+		t.Fatalf("expected the created key to be scrubbed, got %s", replayedKey)
+	}
+	if err = client.DeleteAPIKey(ctx, replayedKey); err != nil {
+		t.Fatalf("error replaying key deletion: %s", err)
+	}
+	if _, err = client.GetPanel(ctx); !errors.Is(err, nanoleaf.ErrUnauthorized) {
+		t.Fatalf("expected the recorded unauthorized error, got %v", err)
+	}
+
+	if remaining := replayer.Remaining(); remaining != 0 {
+		t.Fatalf("expected every interaction to be served, %d remain", remaining)
+	}
+	if _, err = client.GetPanel(ctx); !errors.Is(err, replay.ErrNoInteraction) {
+		t.Fatalf("expected no interaction to match, got %v", err)
+	}
+}
+
+func TestRecorderScrubsHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Location", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	recorder := replay.NewRecorder(nil)
+	client, err := nanoleaf.New("", nanoleaf.WithBaseURL(server.URL), nanoleaf.WithTransport(recorder), nanoleaf.WithAPIKey("secret-key"))
+	if err != nil {
+		t.Fatalf("error creating client: %s", err)
+	}
+	if _, err = client.GetPanel(context.Background()); err != nil {
+		t.Fatalf("error getting panel: %s", err)
+	}
+
+	cassette := recorder.Cassette()
+	if len(cassette.Interactions) != 1 {
+		t.Fatalf("expected 1 interaction, got %d", len(cassette.Interactions))
+	}
+	header := cassette.Interactions[0].Header
+	if location := header.Get("Location"); location != "/api/v1/"+replay.ScrubbedKey+"/" {
+		t.Fatalf("expected the key to be scrubbed from the header, got %s", location)
+	}
+	if len(header.Get("Date")) > 0 || len(header.Get("Content-Length")) > 0 {
+		t.Fatalf("expected the varying headers to be dropped, got %v", header)
+	}
+	if contentType := header.Get("Content-Type"); contentType != "application/json" {
+		t.Fatalf("expected other headers to be kept, got %v", header)
+	}
+}
+
+func TestRecordReplayEvents(t *testing.T) {
+	controller := nanoleaftest.NewController()
+	defer controller.Close()
+
+	recorder := replay.NewRecorder(nil)
+	client := controller.Client(nanoleaf.WithTransport(recorder))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	updates, err := client.Subscribe(ctx, nanoleaf.EventTypeTouch)
+	if err != nil {
+		t.Fatalf("error subscribing: %s", err)
+	}
+
+	gestures := []nanoleaf.Gesture{{GestureType: nanoleaf.GestureSingleTap, PanelID: 101}}
+	controller.SendGestures(gestures...)
+	recorded := nextGestures(t, updates)
+	cancel()
+
+	if !reflect.DeepEqual(recorded, gestures) {
+		t.Fatalf("expected gestures %+v, got %+v", gestures, recorded)
+	}
+
+	replayer := replay.NewReplayer(recorder.Cassette())
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+
+	updates, err = replayClient(t, replayer).Subscribe(ctx, nanoleaf.EventTypeTouch)
+	if err != nil {
+		t.Fatalf("error replaying subscription: %s", err)
+	}
+	if replayed := nextGestures(t, updates); !reflect.DeepEqual(replayed, gestures) {
+		t.Fatalf("expected replayed gestures %+v, got %+v", gestures, replayed)
+	}
+
+	// The recorded stream was closed by the client, so the replayed one stays open until cancelled
+	cancel()
+	select {
+	case _, ok := <-updates:
+		if ok {
+			t.Fatal("expected no further updates")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the updates to end once cancelled")
+	}
+}
+
+// nextGestures returns the gestures of the next update
+func nextGestures(t *testing.T, updates <-chan nanoleaf.PanelUpdate) []nanoleaf.Gesture {
+	t.Helper()
+
+	select {
+	case update, ok := <-updates:
+		if !ok {
+			t.Fatal("expected an update, the stream ended")
+		}
+		return update.Gestures
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for an update")
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package replay
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNoInteraction is returned if a request doesn't match any remaining recorded interaction
+var ErrNoInteraction = errors.New("no matching interaction")
+
+// Replayer is an http.RoundTripper which serves responses from a cassette instead of sending requests.
+// Each request is answered by the first unused interaction with the same method, path (ignoring the API key) and body,
+// so a sequence of identical requests receives the responses recorded for them in order.
+type Replayer struct {
+	// Realtime delays responses and streamed chunks by the time they took when recorded; by default they are served immediately.
+	Realtime bool
+
+	lock         sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// NewReplayer creates a replayer which serves the interactions of the cassette
+func NewReplayer(cassette *Cassette) *Replayer {
+	return &Replayer{
+		interactions: cassette.Interactions,
+		used:         make([]bool, len(cassette.Interactions)),
+	}
+}
+
+// Remaining returns the number of recorded interactions which haven't been served yet
+func (r *Replayer) Remaining() int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	count := 0
+	for _, used := range r.used {
+		if !used {
+			count++
+		}
+	}
+	return count
+}
+
+// RoundTrip serves the recorded response to the request
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		if reqBody, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+	}
+
+	// The key is scrubbed from the body the same way it was when recorded
+	path, key := requestPath(req)
+	body := string(reqBody)
+	if len(key) > 0 {
+		body = strings.ReplaceAll(body, key, ScrubbedKey)
+	}
+
+	interaction, err := r.take(req.Method, path, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.Realtime {
+		if err := sleep(req, interaction.Latency); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(interaction.Error) > 0 {
+		return nil, errors.New(interaction.Error)
+	}
+
+	resp := &http.Response{
+		Status:     fmt.Sprintf("%d %s", interaction.StatusCode, http.StatusText(interaction.StatusCode)),
+		StatusCode: interaction.StatusCode,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     interaction.Header.Clone(),
+		Request:    req,
+	}
+	if resp.Header == nil {
+		resp.Header = http.Header{}
+	}
+
+	if isStream(resp.Header) {
+		resp.ContentLength = -1
+		resp.Body = &replayBody{
+			req:         req,
+			interaction: interaction,
+			realtime:    r.Realtime,
+			start:       time.Now(),
+			closed:      make(chan struct{}),
+		}
+	} else {
+		resp.ContentLength = int64(len(interaction.Body))
+		resp.Body = io.NopCloser(bytes.NewReader([]byte(interaction.Body)))
+	}
+	return resp, nil
+}
+
+func (r *Replayer) take(method string, path string, body string) (Interaction, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for i, interaction := range r.interactions {
+		if r.used[i] || interaction.Method != method || interaction.Path != path || interaction.RequestBody != body {
+			continue
+		}
+
+		r.used[i] = true
+		return interaction, nil
+	}
+	return Interaction{}, fmt.Errorf("%w: %s %s", ErrNoInteraction, method, path)
+}
+
+func sleep(req *http.Request, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-req.Context().Done():
+		return req.Context().Err()
+	}
+}
+
+// replayBody serves the chunks of a streamed response. Once they are exhausted it either ends,
+// if the controller ended the recorded stream, or blocks until the request is cancelled as a live stream would.
+type replayBody struct {
+	req         *http.Request
+	interaction Interaction
+	realtime    bool
+	start       time.Time
+
+	next    int
+	pending []byte
+
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func (b *replayBody) Read(p []byte) (int, error) {
+	if len(b.pending) < 1 {
+		if b.next >= len(b.interaction.Chunks) {
+			if b.interaction.Ended {
+				return 0, io.EOF
+			}
+
+			select {
+			case <-b.req.Context().Done():
+				return 0, b.req.Context().Err()
+			case <-b.closed:
+				return 0, errors.New("replay: read on closed body")
+			}
+		}
+
+		chunk := b.interaction.Chunks[b.next]
+		b.next++
+
+		if b.realtime {
+			if err := sleep(b.req, chunk.Offset-time.Since(b.start)); err != nil {
+				return 0, err
+			}
+		}
+		b.pending = []byte(chunk.Data)
+	}
+
+	n := copy(p, b.pending)
+	b.pending = b.pending[n:]
+	return n, nil
+}
+
+func (b *replayBody) Close() error {
+	b.closeOnce.Do(func() {
+		close(b.closed)
+	})
+	return nil
+}
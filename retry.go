@@ -0,0 +1,88 @@
+package nanoleaf
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"time"
+)
+
+// DefaultTimeout is applied to each request attempt if the context of the call has no deadline
+const DefaultTimeout = 10 * time.Second
+
+// DefaultRetryPolicy is the retry policy used by new clients
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:          3,
+	MinBackoff:           200 * time.Millisecond,
+	MaxBackoff:           2 * time.Second,
+	RetryableStatusCodes: []int{429, 500, 502, 503, 504},
+	RetryNetworkErrors:   true,
+}
+
+// RetryPolicy controls how failed requests are retried.
+// Requests which aren't idempotent (such as incrementing the brightness, deleting an effect or creating or deleting an API key) are only
+// retried if the connection to the panel couldn't be established, as otherwise the panel may have applied them.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts made, including the first. Values below 2 disable retries.
+	MaxAttempts int
+	// MinBackoff is the delay before the first retry; it doubles for each subsequent retry
+	MinBackoff time.Duration
+	// MaxBackoff caps the delay between retries
+	MaxBackoff time.Duration
+	// RetryableStatusCodes are the response status codes which are retried
+	RetryableStatusCodes []int
+	// RetryNetworkErrors controls whether requests failing due to connection errors or timeouts are retried
+	RetryNetworkErrors bool
+}
+
+// shouldRetry indicates whether the request which failed with the specified error can be retried
+func (p RetryPolicy) shouldRetry(err error, idempotent bool) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		if !idempotent {
+			return false
+		}
+		for _, code := range p.RetryableStatusCodes {
+			if apiErr.StatusCode == code {
+				return true
+			}
+		}
+		return false
+	}
+
+	if !p.RetryNetworkErrors {
+		return false
+	}
+
+	// A failure to connect means the panel never received the request, so it is always safe to retry
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+
+	var urlErr *url.Error
+	return idempotent && errors.As(err, &urlErr)
+}
+
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	if p.MinBackoff <= 0 {
+		return 0
+	}
+
+	max := p.MaxBackoff
+	if max < p.MinBackoff {
+		max = p.MinBackoff
+	}
+	return backoff(attempt, p.MinBackoff, max)
+}
+
+// SetRetryPolicy replaces the retry policy of the client. It should be set before the client is used.
+func (c *Client) SetRetryPolicy(policy RetryPolicy) {
+	c.retryPolicy = policy
+}
+
+// SetTimeout replaces the timeout applied to each request attempt when the context has no deadline.
+// A zero value disables the timeout. It should be set before the client is used.
+func (c *Client) SetTimeout(timeout time.Duration) {
+	c.timeout = timeout
+}
@@ -0,0 +1,144 @@
+package nanoleaf_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rmrobinson/nanoleaf-go"
+	"github.com/rmrobinson/nanoleaf-go/nanoleaftest"
+)
+
+// fastRetries retries quickly so tests don't wait on the default backoff
+var fastRetries = nanoleaf.RetryPolicy{
+	MaxAttempts:          3,
+	MinBackoff:           time.Millisecond,
+	MaxBackoff:           time.Millisecond,
+	RetryableStatusCodes: []int{500, 503},
+	RetryNetworkErrors:   true,
+}
+
+type recordingLogger struct {
+	lock  sync.Mutex
+	lines []string
+}
+
+func (l *recordingLogger) Printf(format string, v ...interface{}) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.lines = append(l.lines, fmt.Sprintf(format, v...))
+}
+
+func TestIdempotentRequestsAreRetried(t *testing.T) {
+	controller := nanoleaftest.NewController()
+	defer controller.Close()
+	client := controller.Client(nanoleaf.WithRetryPolicy(fastRetries))
+
+	controller.Fail(http.MethodPut, "effects", 503, 2)
+	if _, err := client.GetEffect(context.Background(), "Forest"); err != nil {
+		t.Fatalf("expected the request to succeed after retrying, got %s", err)
+	}
+
+	controller.Fail(http.MethodGet, "", 500, 3)
+	_, err := client.GetPanel(context.Background())
+
+	var apiErr *nanoleaf.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 500 {
+		t.Fatalf("expected the final 500 once attempts were exhausted, got %v", err)
+	}
+}
+
+func TestNonIdempotentRequestsAreNotRetried(t *testing.T) {
+	tests := []struct {
+		name string
+		call func(*nanoleaf.Client) error
+	}{
+		{"increment brightness", func(c *nanoleaf.Client) error {
+			return c.IncrementBrightness(context.Background(), 10)
+		}},
+		{"incremental state update", func(c *nanoleaf.Client) error {
+			return c.UpdateState(context.Background(), nanoleaf.NewStateUpdate().IncrementHue(10))
+		}},
+		{"rename effect", func(c *nanoleaf.Client) error {
+			return c.RenameEffect(context.Background(), "Forest", "Woods")
+		}},
+		{"delete effect", func(c *nanoleaf.Client) error {
+			return c.DeleteEffect(context.Background(), "Forest")
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			controller := nanoleaftest.NewController()
+			defer controller.Close()
+			client := controller.Client(nanoleaf.WithRetryPolicy(fastRetries))
+
+			// Only the first attempt fails, so a retry would succeed
+			controller.Fail(http.MethodPut, "", 503, 1)
+
+			var apiErr *nanoleaf.APIError
+			if err := test.call(client); !errors.As(err, &apiErr) || apiErr.StatusCode != 503 {
+				t.Fatalf("expected the 503 to be returned without retrying, got %v", err)
+			}
+		})
+	}
+}
+
+func TestDeleteAPIKeyIsNotRetried(t *testing.T) {
+	controller := nanoleaftest.NewController()
+	defer controller.Close()
+	controller.SetPairing(true)
+	client := controller.Client(nanoleaf.WithRetryPolicy(fastRetries))
+
+	key, err := client.CreateAPIKey(context.Background())
+	if err != nil {
+		t.Fatalf("error creating key: %s", err)
+	}
+
+	// The key is deleted but the response is lost, so a retry would be unauthorized
+	controller.FailAfterApplying(http.MethodDelete, "", 503, 1)
+
+	var apiErr *nanoleaf.APIError
+	if err = client.DeleteAPIKey(context.Background(), key); !errors.As(err, &apiErr) || apiErr.StatusCode != 503 {
+		t.Fatalf("expected the 503 to be returned without retrying, got %v", err)
+	}
+	if keys := controller.APIKeys(); len(keys) != 1 || keys[0] != nanoleaftest.DefaultAPIKey {
+		t.Fatalf("expected the key to have been deleted, got %v", keys)
+	}
+}
+
+func TestRetryLogRedactsAPIKey(t *testing.T) {
+	logger := &recordingLogger{}
+
+	// Nothing listens on port 1, so each attempt fails to connect
+	client, err := nanoleaf.New("127.0.0.1",
+		nanoleaf.WithPort(1),
+		nanoleaf.WithAPIKey("SECRETKEY123"),
+		nanoleaf.WithRetryPolicy(fastRetries),
+		nanoleaf.WithLogger(logger))
+	if err != nil {
+		t.Fatalf("error creating client: %s", err)
+	}
+
+	if _, err = client.GetPanel(context.Background()); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	logger.lock.Lock()
+	defer logger.lock.Unlock()
+
+	if len(logger.lines) != fastRetries.MaxAttempts-1 {
+		t.Fatalf("expected a log line per retry, got %v", logger.lines)
+	}
+	for _, line := range logger.lines {
+		if strings.Contains(line, "SECRETKEY123") {
+			t.Fatalf("expected the API key to be redacted, got %s", line)
+		}
+	}
+}
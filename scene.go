@@ -0,0 +1,186 @@
+package nanoleaf
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// latencySamples is the number of requests used to estimate the latency to each controller
+	latencySamples = 3
+	// scheduleLead is extra time allowed before the first scene change is sent, so every send can be scheduled in time
+	scheduleLead = 10 * time.Millisecond
+)
+
+// GroupSceneResult reports how closely the controllers in a group changed scene together
+type GroupSceneResult struct {
+	// Applied holds the estimated time each controller applied the scene, by serial number
+	Applied map[string]time.Time
+	// Skew is the estimated time between the first and last controller applying the scene
+	Skew time.Duration
+}
+
+// SetGroupScene selects the scene on a group of controllers in the fleet (or every controller if none are specified) so they change together.
+// The scene is first checked to exist on every controller, and the latency to each is estimated; if any check fails no scene is changed.
+// The scene changes are then sent so that they are estimated to arrive at the same time, assuming the latency is evenly split between
+// the request and the response. The Parallelism of the fleet is only applied to the checks, as the scene changes must be sent concurrently.
+// Each scene change is sent once, ignoring the retry policy of the client, as a retried change would arrive late and its
+// estimated time would be wrong.
+//
+// If the scene couldn't be changed on some controllers a *FleetError is returned along with the result for the others.
+func (f *Fleet) SetGroupScene(ctx context.Context, sceneName string, serialNumbers ...string) (*GroupSceneResult, error) {
+	clients, err := f.group(serialNumbers)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		lock      sync.Mutex
+		latencies = map[string]time.Duration{}
+	)
+	err = f.do(ctx, clients, func(ctx context.Context, serialNumber string, client *Client) error {
+		latency, err := checkScene(ctx, client, sceneName)
+		if err != nil {
+			return err
+		}
+
+		lock.Lock()
+		latencies[serialNumber] = latency
+		lock.Unlock()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var maxLatency time.Duration
+	for _, latency := range latencies {
+		if latency > maxLatency {
+			maxLatency = latency
+		}
+	}
+	arrival := time.Now().Add(maxLatency + scheduleLead)
+
+	result := &GroupSceneResult{
+		Applied: map[string]time.Time{},
+	}
+	errs := map[string]error{}
+
+	var wg sync.WaitGroup
+	for serialNumber, client := range clients {
+		wg.Add(1)
+		go func(serialNumber string, client *Client) {
+			defer wg.Done()
+
+			applied, err := sendSceneAt(ctx, client, sceneName, arrival.Add(-latencies[serialNumber]))
+
+			lock.Lock()
+			defer lock.Unlock()
+			if err != nil {
+				errs[serialNumber] = err
+				return
+			}
+			result.Applied[serialNumber] = applied
+		}(serialNumber, client)
+	}
+	wg.Wait()
+
+	var first, last time.Time
+	for _, applied := range result.Applied {
+		if first.IsZero() || applied.Before(first) {
+			first = applied
+		}
+		if applied.After(last) {
+			last = applied
+		}
+	}
+	result.Skew = last.Sub(first)
+
+	if len(errs) > 0 {
+		return result, &FleetError{Errors: errs}
+	}
+	return result, nil
+}
+
+// group returns the clients with the specified serial numbers, or every client if none are specified
+func (f *Fleet) group(serialNumbers []string) (map[string]*Client, error) {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	clients := map[string]*Client{}
+	if len(serialNumbers) < 1 {
+		for serialNumber, client := range f.clients {
+			clients[serialNumber] = client
+		}
+		return clients, nil
+	}
+
+	errs := map[string]error{}
+	for _, serialNumber := range serialNumbers {
+		client, ok := f.clients[serialNumber]
+		if !ok {
+			errs[serialNumber] = fmt.Errorf("%w: controller not in fleet", ErrNotFound)
+			continue
+		}
+		clients[serialNumber] = client
+	}
+
+	if len(errs) > 0 {
+		return nil, &FleetError{Errors: errs}
+	}
+	return clients, nil
+}
+
+// checkScene verifies the scene is installed on the controller and estimates the one-way latency to it,
+// using half of the shortest of several round trips
+func checkScene(ctx context.Context, client *Client, sceneName string) (time.Duration, error) {
+	var best time.Duration
+	for i := 0; i < latencySamples; i++ {
+		start := time.Now()
+		panel, err := client.GetPanel(ctx)
+		if err != nil {
+			return 0, err
+		}
+		rtt := time.Since(start)
+
+		if i == 0 {
+			if !hasEffect(panel, sceneName) {
+				return 0, fmt.Errorf("%w: effect %q", ErrNotFound, sceneName)
+			}
+			best = rtt
+		} else if rtt < best {
+			best = rtt
+		}
+	}
+	return best / 2, nil
+}
+
+func hasEffect(panel *LightPanel, sceneName string) bool {
+	for _, option := range panel.Effect.Options {
+		if option == sceneName {
+			return true
+		}
+	}
+	return false
+}
+
+// sendSceneAt waits until the specified time then selects the scene, returning the estimated time the controller applied it.
+// The request is not retried so the estimate is based on the only attempt.
+func sendSceneAt(ctx context.Context, client *Client, sceneName string, sendAt time.Time) (time.Time, error) {
+	timer := time.NewTimer(time.Until(sendAt))
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+		return time.Time{}, ctx.Err()
+	}
+
+	start := time.Now()
+	if err := client.selectScene(ctx, sceneName, false); err != nil {
+		return time.Time{}, err
+	}
+	return start.Add(time.Since(start) / 2), nil
+}
@@ -0,0 +1,62 @@
+package nanoleaf_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/rmrobinson/nanoleaf-go"
+	"github.com/rmrobinson/nanoleaf-go/nanoleaftest"
+)
+
+func TestSetGroupScene(t *testing.T) {
+	first, second := nanoleaftest.NewController(), nanoleaftest.NewController()
+	defer first.Close()
+	defer second.Close()
+
+	fleet := nanoleaf.NewFleet()
+	fleet.AddWithSerial("first", first.Client())
+	fleet.AddWithSerial("second", second.Client())
+
+	result, err := fleet.SetGroupScene(context.Background(), "Forest")
+	if err != nil {
+		t.Fatalf("error setting group scene: %s", err)
+	}
+	if len(result.Applied) != 2 || result.Skew < 0 {
+		t.Fatalf("expected both controllers to apply the scene, got %+v", result)
+	}
+	if first.Panel().Effect.Current != "Forest" || second.Panel().Effect.Current != "Forest" {
+		t.Fatal("expected the scene to be selected on both controllers")
+	}
+
+	if _, err = fleet.SetGroupScene(context.Background(), "Missing"); !errors.Is(err, nanoleaf.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for a missing scene, got %v", err)
+	}
+}
+
+func TestSetGroupSceneIsNotRetried(t *testing.T) {
+	first, second := nanoleaftest.NewController(), nanoleaftest.NewController()
+	defer first.Close()
+	defer second.Close()
+
+	fleet := nanoleaf.NewFleet()
+	fleet.AddWithSerial("first", first.Client(nanoleaf.WithRetryPolicy(fastRetries)))
+	fleet.AddWithSerial("second", second.Client(nanoleaf.WithRetryPolicy(fastRetries)))
+
+	// A retried scene change would arrive late, so the failure is reported instead
+	second.Fail(http.MethodPut, "effects", http.StatusServiceUnavailable, 1)
+
+	result, err := fleet.SetGroupScene(context.Background(), "Forest")
+
+	var fleetErr *nanoleaf.FleetError
+	if !errors.As(err, &fleetErr) || len(fleetErr.Errors) != 1 || fleetErr.Errors["second"] == nil {
+		t.Fatalf("expected only the second controller to fail, got %v", err)
+	}
+	if _, ok := result.Applied["first"]; !ok || len(result.Applied) != 1 {
+		t.Fatalf("expected the first controller to apply the scene, got %+v", result)
+	}
+	if second.Panel().Effect.Current != "Flames" {
+		t.Fatalf("expected the scene change not to be retried, got %s", second.Panel().Effect.Current)
+	}
+}
@@ -0,0 +1,108 @@
+package nanoleaf
+
+import "strconv"
+
+// ShapeType identifies the kind of panel (or other piece) at a position in the layout
+type ShapeType int
+
+const (
+	// ShapeTriangle is a Light Panels triangle
+	ShapeTriangle ShapeType = 0
+	// ShapeRhythm is the Light Panels Rhythm module
+	ShapeRhythm ShapeType = 1
+	// ShapeSquare is a Canvas square
+	ShapeSquare ShapeType = 2
+	// ShapeControlSquareMaster is the Canvas square with the controls which is connected to power
+	ShapeControlSquareMaster ShapeType = 3
+	// ShapeControlSquarePassive is a Canvas square with controls which isn't connected to power
+	ShapeControlSquarePassive ShapeType = 4
+	// ShapeHexagonShapes is a Shapes hexagon
+	ShapeHexagonShapes ShapeType = 7
+	// ShapeTriangleShapes is a Shapes triangle
+	ShapeTriangleShapes ShapeType = 8
+	// ShapeMiniTriangleShapes is a Shapes mini triangle
+	ShapeMiniTriangleShapes ShapeType = 9
+	// ShapeShapesController is the Shapes controller
+	ShapeShapesController ShapeType = 12
+	// ShapeElementsHexagon is an Elements hexagon
+	ShapeElementsHexagon ShapeType = 14
+	// ShapeElementsHexagonCorner is a corner of an Elements hexagon, which are addressed individually
+	ShapeElementsHexagonCorner ShapeType = 15
+	// ShapeLinesConnector is a Lines connector
+	ShapeLinesConnector ShapeType = 16
+	// ShapeLightLines is a Lines light bar
+	ShapeLightLines ShapeType = 17
+	// ShapeLightLinesSingleZone is a Lines light bar which is addressed as a single zone
+	ShapeLightLinesSingleZone ShapeType = 18
+	// ShapeControllerCap is a Lines controller cap
+	ShapeControllerCap ShapeType = 19
+	// ShapePowerConnector is a Lines power connector
+	ShapePowerConnector ShapeType = 20
+)
+
+// ProductFamily is the Nanoleaf product line a shape belongs to
+type ProductFamily string
+
+const (
+	// FamilyUnknown is returned for undocumented shape types
+	FamilyUnknown ProductFamily = ""
+	// FamilyLightPanels is the original triangular Light Panels (Aurora)
+	FamilyLightPanels ProductFamily = "Light Panels"
+	// FamilyCanvas is the square Canvas panels
+	FamilyCanvas ProductFamily = "Canvas"
+	// FamilyShapes is the Shapes hexagons and triangles
+	FamilyShapes ProductFamily = "Shapes"
+	// FamilyElements is the wood-look Elements hexagons
+	FamilyElements ProductFamily = "Elements"
+	// FamilyLines is the Lines light bars
+	FamilyLines ProductFamily = "Lines"
+)
+
+type shapeInfo struct {
+	name       string
+	sideLength float64
+	hasLEDs    bool
+	family     ProductFamily
+}
+
+var shapeInfos = map[ShapeType]shapeInfo{
+	ShapeTriangle:              {"Triangle", 150, true, FamilyLightPanels},
+	ShapeRhythm:                {"Rhythm", 0, false, FamilyLightPanels},
+	ShapeSquare:                {"Square", 100, true, FamilyCanvas},
+	ShapeControlSquareMaster:   {"Control Square Master", 100, true, FamilyCanvas},
+	ShapeControlSquarePassive:  {"Control Square Passive", 100, true, FamilyCanvas},
+	ShapeHexagonShapes:         {"Hexagon (Shapes)", 67, true, FamilyShapes},
+	ShapeTriangleShapes:        {"Triangle (Shapes)", 134, true, FamilyShapes},
+	ShapeMiniTriangleShapes:    {"Mini Triangle (Shapes)", 67, true, FamilyShapes},
+	ShapeShapesController:      {"Shapes Controller", 0, false, FamilyShapes},
+	ShapeElementsHexagon:       {"Elements Hexagon", 134, true, FamilyElements},
+	ShapeElementsHexagonCorner: {"Elements Hexagon Corner", 58, true, FamilyElements},
+	ShapeLinesConnector:        {"Lines Connector", 11, false, FamilyLines},
+	ShapeLightLines:            {"Light Lines", 154, true, FamilyLines},
+	ShapeLightLinesSingleZone:  {"Light Lines Single Zone", 77, true, FamilyLines},
+	ShapeControllerCap:         {"Controller Cap", 11, false, FamilyLines},
+	ShapePowerConnector:        {"Power Connector", 11, false, FamilyLines},
+}
+
+// String returns the name of the shape
+func (st ShapeType) String() string {
+	if info, ok := shapeInfos[st]; ok {
+		return info.name
+	}
+	return "ShapeType(" + strconv.Itoa(int(st)) + ")"
+}
+
+// SideLength returns the length of a side of the shape in layout units, or 0 if the shape has no size in the layout
+func (st ShapeType) SideLength() float64 {
+	return shapeInfos[st].sideLength
+}
+
+// HasLEDs indicates whether the shape can be lit, and so can be addressed by effects and streaming
+func (st ShapeType) HasLEDs() bool {
+	return shapeInfos[st].hasLEDs
+}
+
+// Family returns the product family the shape belongs to
+func (st ShapeType) Family() ProductFamily {
+	return shapeInfos[st].family
+}
@@ -0,0 +1,39 @@
+package nanoleaf
+
+import "testing"
+
+func TestShapeTypeMetadata(t *testing.T) {
+	tests := []struct {
+		shapeType  ShapeType
+		name       string
+		sideLength float64
+		hasLEDs    bool
+		family     ProductFamily
+	}{
+		{ShapeTriangle, "Triangle", 150, true, FamilyLightPanels},
+		{ShapeRhythm, "Rhythm", 0, false, FamilyLightPanels},
+		{ShapeSquare, "Square", 100, true, FamilyCanvas},
+		{ShapeHexagonShapes, "Hexagon (Shapes)", 67, true, FamilyShapes},
+		{ShapeShapesController, "Shapes Controller", 0, false, FamilyShapes},
+		{ShapeElementsHexagon, "Elements Hexagon", 134, true, FamilyElements},
+		{ShapeElementsHexagonCorner, "Elements Hexagon Corner", 58, true, FamilyElements},
+		{ShapeLightLines, "Light Lines", 154, true, FamilyLines},
+		{ShapeLinesConnector, "Lines Connector", 11, false, FamilyLines},
+		{ShapeType(99), "ShapeType(99)", 0, false, FamilyUnknown},
+	}
+
+	for _, test := range tests {
+		if test.shapeType.String() != test.name {
+			t.Errorf("expected %d to be named %s, got %s", int(test.shapeType), test.name, test.shapeType.String())
+		}
+		if test.shapeType.SideLength() != test.sideLength {
+			t.Errorf("expected %s to have side length %f, got %f", test.name, test.sideLength, test.shapeType.SideLength())
+		}
+		if test.shapeType.HasLEDs() != test.hasLEDs {
+			t.Errorf("expected %s HasLEDs to be %t", test.name, test.hasLEDs)
+		}
+		if test.shapeType.Family() != test.family {
+			t.Errorf("expected %s to be in family %q, got %q", test.name, test.family, test.shapeType.Family())
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package nanoleaf
+
+import (
+	"context"
+	"image/color"
+	"math"
+	"time"
+)
+
+// ColorFunc computes the colour at a position in the layout at a point in time.
+// The position is normalized so that x and y range from 0 to 1 across the panels, with y increasing upwards.
+// A nil colour is treated as black.
+type ColorFunc func(x, y float64, t time.Duration) color.Color
+
+// SpatialLayout computes per-panel colours from the position of each panel in a layout.
+// Only panels which can be lit are included.
+type SpatialLayout struct {
+	panels []spatialPanel
+	// minX, minY, maxX and maxY are the bounds of the panel positions, after the orientation is applied
+	minX float64
+	minY float64
+	maxX float64
+	maxY float64
+}
+
+type spatialPanel struct {
+	position PanelPosition
+	x        float64
+	y        float64
+}
+
+// NewSpatialLayout creates a spatial layout from the panel layout, applying the global orientation
+func NewSpatialLayout(layout PanelLayout) *SpatialLayout {
+	sl := &SpatialLayout{}
+	rotation := layout.Rotation()
+
+	for _, pos := range layout.Panels.Panels {
+		if !pos.Type.HasLEDs() {
+			continue
+		}
+
+		p := spatialPanel{position: pos}
+		p.x, p.y = rotation.Apply(float64(pos.X), float64(pos.Y))
+
+		if len(sl.panels) < 1 {
+			sl.minX, sl.maxX, sl.minY, sl.maxY = p.x, p.x, p.y, p.y
+		} else {
+			sl.minX, sl.maxX = math.Min(sl.minX, p.x), math.Max(sl.maxX, p.x)
+			sl.minY, sl.maxY = math.Min(sl.minY, p.y), math.Max(sl.maxY, p.y)
+		}
+		sl.panels = append(sl.panels, p)
+	}
+
+	return sl
+}
+
+// Panels returns the positions of the panels in the layout
+func (sl *SpatialLayout) Panels() []PanelPosition {
+	positions := make([]PanelPosition, len(sl.panels))
+	for i, p := range sl.panels {
+		positions[i] = p.position
+	}
+	return positions
+}
+
+// Normalized returns the normalized position of the panel, or false if the panel isn't in the layout
+func (sl *SpatialLayout) Normalized(panelID int) (float64, float64, bool) {
+	for _, p := range sl.panels {
+		if p.position.PanelID == panelID {
+			x, y := sl.normalize(p)
+			return x, y, true
+		}
+	}
+	return 0, 0, false
+}
+
+func (sl *SpatialLayout) normalize(p spatialPanel) (float64, float64) {
+	x, y := 0.5, 0.5
+	if width := sl.maxX - sl.minX; width > 0 {
+		x = (p.x - sl.minX) / width
+	}
+	if height := sl.maxY - sl.minY; height > 0 {
+		y = (p.y - sl.minY) / height
+	}
+	return x, y
+}
+
+// Nearest returns the panel closest to the normalized position, or false if the layout has no panels
+func (sl *SpatialLayout) Nearest(x, y float64) (PanelPosition, bool) {
+	var nearest PanelPosition
+	best := math.Inf(1)
+
+	for _, p := range sl.panels {
+		px, py := sl.normalize(p)
+		// Compare in layout units so the aspect ratio of the layout doesn't skew the result
+		d := math.Hypot((px-x)*(sl.maxX-sl.minX), (py-y)*(sl.maxY-sl.minY))
+		if d < best {
+			best = d
+			nearest = p.position
+		}
+	}
+
+	return nearest, !math.IsInf(best, 1)
+}
+
+// Frames evaluates the function for every panel at the specified time, producing frames which can be streamed.
+// The transition time is in multiples of 100ms.
+func (sl *SpatialLayout) Frames(fn ColorFunc, t time.Duration, transitionTime int) []Frame {
+	frames := make([]Frame, 0, len(sl.panels))
+	for _, p := range sl.panels {
+		x, y := sl.normalize(p)
+		rgba := evaluate(fn, x, y, t)
+
+		frames = append(frames, Frame{
+			PanelID:        p.position.PanelID,
+			Red:            rgba.R,
+			Green:          rgba.G,
+			Blue:           rgba.B,
+			TransitionTime: transitionTime,
+		})
+	}
+	return frames
+}
+
+// Animation evaluates the function for every panel at each interval, producing an animation of the specified
+// number of keyframes which can be used as the animation data of a custom effect.
+func (sl *SpatialLayout) Animation(fn ColorFunc, frameCount int, interval time.Duration) *Animation {
+	transitionTime := int(interval / (100 * time.Millisecond))
+
+	anim := &Animation{}
+	for _, p := range sl.panels {
+		x, y := sl.normalize(p)
+		panel := PanelAnimation{PanelID: p.position.PanelID}
+
+		for i := 0; i < frameCount; i++ {
+			rgba := evaluate(fn, x, y, time.Duration(i)*interval)
+			panel.Frames = append(panel.Frames, Keyframe{
+				Red:            int(rgba.R),
+				Green:          int(rgba.G),
+				Blue:           int(rgba.B),
+				TransitionTime: transitionTime,
+			})
+		}
+
+		anim.Panels = append(anim.Panels, panel)
+	}
+	return anim
+}
+
+// Stream sends frames computed from the function to the streamer at each interval until the context is done
+func (sl *SpatialLayout) Stream(ctx context.Context, s *Streamer, fn ColorFunc, interval time.Duration) error {
+	transitionTime := int(interval / (100 * time.Millisecond))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	start := time.Now()
+	for {
+		if err := s.Send(sl.Frames(fn, time.Since(start), transitionTime)); err != nil {
+			return err
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// evaluate returns the colour computed by the function, treating nil as black
+func evaluate(fn ColorFunc, x, y float64, t time.Duration) color.RGBA {
+	c := fn(x, y, t)
+	if c == nil {
+		return color.RGBA{A: 0xff}
+	}
+	return color.RGBAModel.Convert(c).(color.RGBA)
+}
+
+// LinearGradient blends the colours evenly across the layout in the direction of the angle,
+// in degrees counter-clockwise from the positive x axis
+func LinearGradient(angle float64, stops ...color.Color) ColorFunc {
+	rad := angle * math.Pi / 180
+	dx, dy := math.Cos(rad), math.Sin(rad)
+
+	// Project the corners of the unit square to find the range of positions along the direction
+	min := math.Min(0, dx) + math.Min(0, dy)
+	max := math.Max(0, dx) + math.Max(0, dy)
+
+	return func(x, y float64, t time.Duration) color.Color {
+		pos := 0.0
+		if max > min {
+			pos = (x*dx + y*dy - min) / (max - min)
+		}
+		return blendStops(stops, pos)
+	}
+}
+
+// RadialWave sends rings of colour outwards from the normalized centre point, blending between the two colours.
+// The wavelength is in normalized units and the period is the time for a ring to move one wavelength.
+func RadialWave(cx, cy float64, wavelength float64, period time.Duration, from, to color.Color) ColorFunc {
+	return func(x, y float64, t time.Duration) color.Color {
+		phase := math.Hypot(x-cx, y-cy) / wavelength
+		if period > 0 {
+			phase -= float64(t) / float64(period)
+		}
+		return blend(from, to, (1-math.Cos(2*math.Pi*phase))/2)
+	}
+}
+
+// RainbowSweep moves the full range of hues across the layout from left to right, once per period
+func RainbowSweep(period time.Duration) ColorFunc {
+	return func(x, y float64, t time.Duration) color.Color {
+		offset := x
+		if period > 0 {
+			offset -= float64(t) / float64(period)
+		}
+
+		hue := math.Mod(offset*360, 360)
+		if hue < 0 {
+			hue += 360
+		}
+		return HSB{Hue: int(hue), Saturation: 100, Brightness: 100}
+	}
+}
+
+// blendStops picks the colour at the position (0-1) along evenly spaced stops
+func blendStops(stops []color.Color, pos float64) color.Color {
+	if len(stops) < 1 {
+		return color.Black
+	} else if len(stops) == 1 {
+		return stops[0]
+	}
+
+	pos = clamp(pos, 0, 1) * float64(len(stops)-1)
+	idx := int(math.Floor(pos))
+	if idx >= len(stops)-1 {
+		return stops[len(stops)-1]
+	}
+	return blend(stops[idx], stops[idx+1], pos-float64(idx))
+}
+
+// blend linearly interpolates between the colours; amount is 0 for the first colour and 1 for the second
+func blend(from, to color.Color, amount float64) color.Color {
+	fr, fg, fb := unitRGB(from)
+	tr, tg, tb := unitRGB(to)
+	amount = clamp(amount, 0, 1)
+
+	return color.RGBA{
+		R: uint8(math.Round((fr + (tr-fr)*amount) * 255)),
+		G: uint8(math.Round((fg + (tg-fg)*amount) * 255)),
+		B: uint8(math.Round((fb + (tb-fb)*amount) * 255)),
+		A: 0xff,
+	}
+}
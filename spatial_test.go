@@ -0,0 +1,169 @@
+package nanoleaf_test
+
+import (
+	"context"
+	"errors"
+	"image/color"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/rmrobinson/nanoleaf-go"
+	"github.com/rmrobinson/nanoleaf-go/nanoleaftest"
+)
+
+// testSpatialLayout has three triangles forming a right angle, plus a controller which can't be lit
+func testSpatialLayout(orientation int) nanoleaf.PanelLayout {
+	return nanoleaf.PanelLayout{
+		Orientation: nanoleaf.IntRangeValue{Value: orientation, Max: 360},
+		Panels: nanoleaf.Layout{
+			PanelCount: 4,
+			Panels: []nanoleaf.PanelPosition{
+				{PanelID: 1, X: 0, Y: 0, Type: nanoleaf.ShapeTriangle},
+				{PanelID: 2, X: 100, Y: 0, Type: nanoleaf.ShapeTriangle},
+				{PanelID: 3, X: 100, Y: 50, Type: nanoleaf.ShapeTriangle},
+				{PanelID: 4, X: 500, Y: 500, Type: nanoleaf.ShapeControllerCap},
+			},
+		},
+	}
+}
+
+func TestSpatialLayoutNormalized(t *testing.T) {
+	tests := []struct {
+		name        string
+		orientation int
+		expected    map[int][2]float64
+	}{
+		{
+			name:     "unrotated",
+			expected: map[int][2]float64{1: {0, 0}, 2: {1, 0}, 3: {1, 1}},
+		},
+		{
+			name:        "rotated",
+			orientation: 90,
+			expected:    map[int][2]float64{1: {1, 0}, 2: {1, 1}, 3: {0, 1}},
+		},
+	}
+
+	for _, test := range tests {
+		sl := nanoleaf.NewSpatialLayout(testSpatialLayout(test.orientation))
+		if count := len(sl.Panels()); count != len(test.expected) {
+			t.Errorf("%s: expected %d panels, got %d", test.name, len(test.expected), count)
+		}
+
+		for id, pos := range test.expected {
+			x, y, ok := sl.Normalized(id)
+			if !ok {
+				t.Errorf("%s: expected panel %d to be in the layout", test.name, id)
+			} else if math.Abs(x-pos[0]) > 1e-9 || math.Abs(y-pos[1]) > 1e-9 {
+				t.Errorf("%s: expected panel %d at %v, got (%g, %g)", test.name, id, pos, x, y)
+			}
+		}
+
+		if _, _, ok := sl.Normalized(4); ok {
+			t.Errorf("%s: expected the controller to be excluded", test.name)
+		}
+	}
+}
+
+func TestSpatialLayoutNearest(t *testing.T) {
+	sl := nanoleaf.NewSpatialLayout(testSpatialLayout(0))
+
+	if pos, ok := sl.Nearest(0.9, 0.1); !ok || pos.PanelID != 2 {
+		t.Errorf("expected panel 2 to be nearest, got %d (%t)", pos.PanelID, ok)
+	}
+	if pos, ok := sl.Nearest(0.6, 0.9); !ok || pos.PanelID != 3 {
+		t.Errorf("expected panel 3 to be nearest, got %d (%t)", pos.PanelID, ok)
+	}
+
+	empty := nanoleaf.NewSpatialLayout(nanoleaf.PanelLayout{})
+	if _, ok := empty.Nearest(0.5, 0.5); ok {
+		t.Error("expected no panel in an empty layout")
+	}
+}
+
+func TestSpatialLayoutFrames(t *testing.T) {
+	sl := nanoleaf.NewSpatialLayout(testSpatialLayout(0))
+
+	frames := sl.Frames(nanoleaf.LinearGradient(0, color.Black, color.White), 0, 3)
+	expected := map[int]uint8{1: 0, 2: 255, 3: 255}
+	if len(frames) != len(expected) {
+		t.Fatalf("expected %d frames, got %d", len(expected), len(frames))
+	}
+	for _, frame := range frames {
+		if frame.Red != expected[frame.PanelID] || frame.Green != frame.Red || frame.Blue != frame.Red {
+			t.Errorf("expected panel %d to be grey %d, got %v", frame.PanelID, expected[frame.PanelID], frame)
+		} else if frame.TransitionTime != 3 {
+			t.Errorf("expected transition time 3, got %d", frame.TransitionTime)
+		}
+	}
+}
+
+func TestSpatialLayoutNilColor(t *testing.T) {
+	sl := nanoleaf.NewSpatialLayout(testSpatialLayout(0))
+	fn := func(x, y float64, t time.Duration) color.Color {
+		if t > 0 {
+			return color.White
+		}
+		return nil
+	}
+
+	for _, frame := range sl.Frames(fn, 0, 1) {
+		if frame.Red != 0 || frame.Green != 0 || frame.Blue != 0 {
+			t.Errorf("expected a nil colour to be black, got %v", frame)
+		}
+	}
+
+	anim := sl.Animation(fn, 2, 200*time.Millisecond)
+	if len(anim.Panels) != 3 {
+		t.Fatalf("expected 3 panels, got %d", len(anim.Panels))
+	}
+	for _, panel := range anim.Panels {
+		expected := []nanoleaf.Keyframe{
+			{TransitionTime: 2},
+			{Red: 255, Green: 255, Blue: 255, TransitionTime: 2},
+		}
+		if len(panel.Frames) != len(expected) || panel.Frames[0] != expected[0] || panel.Frames[1] != expected[1] {
+			t.Errorf("expected panel %d frames %v, got %v", panel.PanelID, expected, panel.Frames)
+		}
+	}
+}
+
+func TestSpatialLayoutStream(t *testing.T) {
+	controller := nanoleaftest.NewController()
+	defer controller.Close()
+
+	client := controller.Client()
+	panel, err := client.GetPanel(context.Background())
+	if err != nil {
+		t.Fatalf("error getting panel: %s", err)
+	}
+	streamer, err := client.StartStream(context.Background(), nanoleaf.StreamV1)
+	if err != nil {
+		t.Fatalf("error starting stream: %s", err)
+	}
+	defer streamer.Close()
+
+	sl := nanoleaf.NewSpatialLayout(panel.Layout)
+	panelCount := len(sl.Panels())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- sl.Stream(ctx, streamer, nanoleaf.RainbowSweep(time.Second), 10*time.Millisecond)
+	}()
+
+	// Wait for at least two updates of every panel
+	deadline := time.Now().Add(5 * time.Second)
+	for len(controller.StreamedFrames()) < 2*panelCount && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+
+	if err = <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the stream to end when cancelled, got %v", err)
+	}
+	if received := len(controller.StreamedFrames()); received < 2*panelCount {
+		t.Fatalf("expected at least %d frames, got %d", 2*panelCount, received)
+	}
+}
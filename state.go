@@ -0,0 +1,130 @@
+package nanoleaf
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// StateUpdate collects changes to the panel state so they can be applied together by UpdateState.
+// The setters can be chained, e.g. NewStateUpdate().On(true).Hue(120).Saturation(100).Brightness(80, 0)
+type StateUpdate struct {
+	on         *BoolValue
+	brightness *stateChange
+	hue        *stateChange
+	saturation *stateChange
+	ct         *stateChange
+}
+
+// stateChange is either an absolute value or an increment; duration is only supported for brightness
+type stateChange struct {
+	Value     *int `json:"value,omitempty"`
+	Increment *int `json:"increment,omitempty"`
+	Duration  int  `json:"duration,omitempty"`
+}
+
+func (sc *stateChange) isIncrement() bool {
+	return sc != nil && sc.Increment != nil
+}
+
+func setTo(value int) *stateChange {
+	return &stateChange{Value: &value}
+}
+
+func incrementBy(amount int) *stateChange {
+	return &stateChange{Increment: &amount}
+}
+
+// NewStateUpdate creates an empty state update
+func NewStateUpdate() StateUpdate {
+	return StateUpdate{}
+}
+
+// On sets the panel to either be on or off
+func (su StateUpdate) On(on bool) StateUpdate {
+	su.on = &BoolValue{Value: on}
+	return su
+}
+
+// Brightness sets the brightness level and (optionally) the duration in seconds to transition over
+func (su StateUpdate) Brightness(level int, duration int) StateUpdate {
+	su.brightness = setTo(level)
+	su.brightness.Duration = duration
+	return su
+}
+
+// IncrementBrightness increments the brightness level. Both positive and negative values are supported.
+func (su StateUpdate) IncrementBrightness(amount int) StateUpdate {
+	su.brightness = incrementBy(amount)
+	return su
+}
+
+// Hue sets the hue of the light
+func (su StateUpdate) Hue(hue int) StateUpdate {
+	su.hue = setTo(hue)
+	return su
+}
+
+// IncrementHue increments the hue of the light. Both positive and negative values are supported.
+func (su StateUpdate) IncrementHue(amount int) StateUpdate {
+	su.hue = incrementBy(amount)
+	return su
+}
+
+// Saturation sets the saturation of the light
+func (su StateUpdate) Saturation(sat int) StateUpdate {
+	su.saturation = setTo(sat)
+	return su
+}
+
+// IncrementSaturation increments the saturation of the light. Both positive and negative values are supported.
+func (su StateUpdate) IncrementSaturation(amount int) StateUpdate {
+	su.saturation = incrementBy(amount)
+	return su
+}
+
+// CT sets the colour temperature of the light
+func (su StateUpdate) CT(ct int) StateUpdate {
+	su.ct = setTo(ct)
+	return su
+}
+
+// IncrementCT increments the colour temperature of the light. Both positive and negative values are supported.
+func (su StateUpdate) IncrementCT(amount int) StateUpdate {
+	su.ct = incrementBy(amount)
+	return su
+}
+
+// IsEmpty indicates whether the update doesn't change anything
+func (su StateUpdate) IsEmpty() bool {
+	return su.on == nil && su.brightness == nil && su.hue == nil && su.saturation == nil && su.ct == nil
+}
+
+// MarshalJSON serializes the update as the body of a state request
+func (su StateUpdate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		On         *BoolValue   `json:"on,omitempty"`
+		Brightness *stateChange `json:"brightness,omitempty"`
+		Hue        *stateChange `json:"hue,omitempty"`
+		Saturation *stateChange `json:"sat,omitempty"`
+		CT         *stateChange `json:"ct,omitempty"`
+	}{
+		On:         su.on,
+		Brightness: su.brightness,
+		Hue:        su.hue,
+		Saturation: su.saturation,
+		CT:         su.ct,
+	})
+}
+
+// UpdateState applies all the changes in the update to the panel in a single request, so they take effect together.
+// Updates containing increments are not retried unless the panel couldn't be reached, as they aren't idempotent.
+func (c *Client) UpdateState(ctx context.Context, update StateUpdate) error {
+	if update.IsEmpty() {
+		return nil
+	}
+
+	if update.brightness.isIncrement() || update.hue.isIncrement() || update.saturation.isIncrement() || update.ct.isIncrement() {
+		return c.putNonIdempotent(ctx, "state", update, nil)
+	}
+	return c.put(ctx, "state", update, nil)
+}
@@ -0,0 +1,144 @@
+package nanoleaf
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"strconv"
+)
+
+// StreamVersion identifies the version of the external control streaming protocol
+type StreamVersion string
+
+const (
+	// StreamV1 is the original protocol supported by the Light Panels; panel IDs and transition times are limited to 255
+	StreamV1 StreamVersion = "v1"
+	// StreamV2 is the protocol supported by Canvas, Shapes and newer firmware on the Light Panels
+	StreamV2 StreamVersion = "v2"
+
+	streamV2Port = 60222
+)
+
+var (
+	// ErrFrameOutOfRange is returned if a frame can't be represented in the selected stream version
+	ErrFrameOutOfRange = errors.New("frame out of range")
+)
+
+// Frame sets the colour of a single panel in a stream
+type Frame struct {
+	PanelID int
+	Red     uint8
+	Green   uint8
+	Blue    uint8
+	// White is currently ignored by the panels but is part of the protocol
+	White uint8
+	// TransitionTime is the time to fade to this colour, in multiples of 100ms
+	TransitionTime int
+}
+
+// StartStream switches the panel into external control mode and returns a Streamer to send frames to it.
+// The panel stays in external control mode until another effect is selected.
+func (c *Client) StartStream(ctx context.Context, version StreamVersion) (*Streamer, error) {
+	var req struct {
+		Body struct {
+			Command        string        `json:"command"`
+			AnimationType  string        `json:"animType"`
+			ControlVersion StreamVersion `json:"extControlVersion,omitempty"`
+		} `json:"write"`
+	}
+
+	req.Body.Command = "display"
+	req.Body.AnimationType = "extControl"
+	if version != StreamV1 {
+		req.Body.ControlVersion = version
+	}
+
+	var resp struct {
+		Address  string `json:"streamControlIpAddr"`
+		Port     int    `json:"streamControlPort"`
+		Protocol string `json:"streamControlProtocol"`
+	}
+	err := c.put(ctx, "effects", req, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	addr := net.JoinHostPort(c.hostname, strconv.Itoa(streamV2Port))
+	if version == StreamV1 && len(resp.Address) > 0 {
+		addr = net.JoinHostPort(resp.Address, strconv.Itoa(resp.Port))
+	}
+
+	return DialStreamer(addr, version)
+}
+
+// Streamer sends frames to a panel in external control mode
+type Streamer struct {
+	conn    net.Conn
+	version StreamVersion
+}
+
+// DialStreamer creates a Streamer which sends frames to the specified UDP address.
+// StartStream should generally be used instead as it also switches the panel into external control mode.
+func DialStreamer(addr string, version StreamVersion) (*Streamer, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Streamer{
+		conn:    conn,
+		version: version,
+	}, nil
+}
+
+// Send updates the panels with the specified frames. Panels not included keep their current colour.
+func (s *Streamer) Send(frames []Frame) error {
+	b, err := encodeFrames(s.version, frames)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.conn.Write(b)
+	return err
+}
+
+// Close releases the connection used by the streamer
+func (s *Streamer) Close() error {
+	return s.conn.Close()
+}
+
+func encodeFrames(version StreamVersion, frames []Frame) ([]byte, error) {
+	if version == StreamV1 {
+		if len(frames) > 255 {
+			return nil, ErrFrameOutOfRange
+		}
+
+		b := make([]byte, 0, 1+len(frames)*7)
+		b = append(b, byte(len(frames)))
+		for _, f := range frames {
+			if f.PanelID < 0 || f.PanelID > 255 || f.TransitionTime < 0 || f.TransitionTime > 255 {
+				return nil, ErrFrameOutOfRange
+			}
+			// Each panel entry carries a frame count, which is always 1 when streaming
+			b = append(b, byte(f.PanelID), 1, f.Red, f.Green, f.Blue, f.White, byte(f.TransitionTime))
+		}
+		return b, nil
+	}
+
+	if len(frames) > 65535 {
+		return nil, ErrFrameOutOfRange
+	}
+
+	b := make([]byte, 0, 2+len(frames)*8)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(frames)))
+	for _, f := range frames {
+		if f.PanelID < 0 || f.PanelID > 65535 || f.TransitionTime < 0 || f.TransitionTime > 65535 {
+			return nil, ErrFrameOutOfRange
+		}
+		b = binary.BigEndian.AppendUint16(b, uint16(f.PanelID))
+		b = append(b, f.Red, f.Green, f.Blue, f.White)
+		b = binary.BigEndian.AppendUint16(b, uint16(f.TransitionTime))
+	}
+	return b, nil
+}
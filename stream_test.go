@@ -0,0 +1,165 @@
+package nanoleaf_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rmrobinson/nanoleaf-go"
+	"github.com/rmrobinson/nanoleaf-go/nanoleaftest"
+)
+
+// listenUDP opens a local socket for a streamer to send to
+func listenUDP(t *testing.T) net.PacketConn {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening: %s", err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+	})
+	return conn
+}
+
+// readPacket returns the next packet received on the socket
+func readPacket(t *testing.T, conn net.PacketConn) []byte {
+	t.Helper()
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 65536)
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("error reading packet: %s", err)
+	}
+	return buf[:n]
+}
+
+func TestStreamerEncodesPackets(t *testing.T) {
+	frames := []nanoleaf.Frame{
+		{PanelID: 12, Red: 255, Green: 128, Blue: 1, White: 0, TransitionTime: 3},
+		{PanelID: 255, Red: 0, Green: 0, Blue: 9, White: 7, TransitionTime: 255},
+	}
+
+	tests := []struct {
+		name    string
+		version nanoleaf.StreamVersion
+		frames  []nanoleaf.Frame
+		packet  []byte
+	}{
+		{
+			name:    "v1",
+			version: nanoleaf.StreamV1,
+			frames:  frames,
+			packet: []byte{
+				2,
+				12, 1, 255, 128, 1, 0, 3,
+				255, 1, 0, 0, 9, 7, 255,
+			},
+		},
+		{
+			name:    "v2",
+			version: nanoleaf.StreamV2,
+			frames:  append(frames, nanoleaf.Frame{PanelID: 4660, Red: 1, Green: 2, Blue: 3, TransitionTime: 600}),
+			packet: []byte{
+				0, 3,
+				0, 12, 255, 128, 1, 0, 0, 3,
+				0, 255, 0, 0, 9, 7, 0, 255,
+				0x12, 0x34, 1, 2, 3, 0, 0x02, 0x58,
+			},
+		},
+		{
+			name:    "v1 empty",
+			version: nanoleaf.StreamV1,
+			packet:  []byte{0},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			conn := listenUDP(t)
+
+			streamer, err := nanoleaf.DialStreamer(conn.LocalAddr().String(), test.version)
+			if err != nil {
+				t.Fatalf("error dialing: %s", err)
+			}
+			defer streamer.Close()
+
+			if err = streamer.Send(test.frames); err != nil {
+				t.Fatalf("error sending: %s", err)
+			}
+
+			if packet := readPacket(t, conn); !reflect.DeepEqual(packet, test.packet) {
+				t.Fatalf("expected packet %v, got %v", test.packet, packet)
+			}
+		})
+	}
+}
+
+func TestStreamerRejectsFramesOutOfRange(t *testing.T) {
+	tooMany := make([]nanoleaf.Frame, 256)
+	for i := range tooMany {
+		tooMany[i].PanelID = i
+	}
+
+	tests := []struct {
+		name    string
+		version nanoleaf.StreamVersion
+		frames  []nanoleaf.Frame
+	}{
+		{"v1 panel ID", nanoleaf.StreamV1, []nanoleaf.Frame{{PanelID: 256}}},
+		{"v1 transition time", nanoleaf.StreamV1, []nanoleaf.Frame{{PanelID: 1, TransitionTime: 256}}},
+		{"v1 frame count", nanoleaf.StreamV1, tooMany},
+		{"v1 negative panel ID", nanoleaf.StreamV1, []nanoleaf.Frame{{PanelID: -1}}},
+		{"v2 panel ID", nanoleaf.StreamV2, []nanoleaf.Frame{{PanelID: 65536}}},
+		{"v2 transition time", nanoleaf.StreamV2, []nanoleaf.Frame{{PanelID: 1, TransitionTime: 65536}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			conn := listenUDP(t)
+
+			streamer, err := nanoleaf.DialStreamer(conn.LocalAddr().String(), test.version)
+			if err != nil {
+				t.Fatalf("error dialing: %s", err)
+			}
+			defer streamer.Close()
+
+			if err = streamer.Send(test.frames); !errors.Is(err, nanoleaf.ErrFrameOutOfRange) {
+				t.Fatalf("expected ErrFrameOutOfRange, got %v", err)
+			}
+		})
+	}
+}
+
+func TestStartStream(t *testing.T) {
+	controller := nanoleaftest.NewController()
+	defer controller.Close()
+
+	streamer, err := controller.Client().StartStream(context.Background(), nanoleaf.StreamV1)
+	if err != nil {
+		t.Fatalf("error starting stream: %s", err)
+	}
+	defer streamer.Close()
+
+	if current := controller.Panel().Effect.Current; current != nanoleaftest.ExtControlEffect {
+		t.Fatalf("expected external control mode, got effect %s", current)
+	}
+
+	frames := []nanoleaf.Frame{{PanelID: 101, Red: 10, Green: 20, Blue: 30, TransitionTime: 1}}
+	if err = streamer.Send(frames); err != nil {
+		t.Fatalf("error sending: %s", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(controller.StreamedFrames()) < 1 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if received := controller.StreamedFrames(); !reflect.DeepEqual(received, frames) {
+		t.Fatalf("expected frames %v, got %v", frames, received)
+	}
+}
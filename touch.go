@@ -0,0 +1,167 @@
+package nanoleaf
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+)
+
+var (
+	// ErrMalformedTouchPacket is returned if a touch data packet can't be decoded
+	ErrMalformedTouchPacket = errors.New("malformed touch packet")
+)
+
+// TouchType identifies the kind of touch detected on a panel
+type TouchType int
+
+const (
+	// TouchHover is reported while a hand is close to, but not touching, the panel
+	TouchHover TouchType = 0
+	// TouchDown is reported when the panel is first touched
+	TouchDown TouchType = 1
+	// TouchHold is reported while the panel continues to be touched
+	TouchHold TouchType = 2
+	// TouchUp is reported when the touch is released
+	TouchUp TouchType = 3
+	// TouchSwipe is reported when the touch moves onto the panel from another panel
+	TouchSwipe TouchType = 4
+)
+
+// String returns the name of the touch type
+func (tt TouchType) String() string {
+	switch tt {
+	case TouchHover:
+		return "Hover"
+	case TouchDown:
+		return "Down"
+	case TouchHold:
+		return "Hold"
+	case TouchUp:
+		return "Up"
+	case TouchSwipe:
+		return "Swipe"
+	}
+	return "TouchType(" + strconv.Itoa(int(tt)) + ")"
+}
+
+// TouchEvent contains the touch data of a single panel
+type TouchEvent struct {
+	PanelID int
+	Type    TouchType
+	// Strength is a 0-15 value
+	Strength int
+	// SwipedFromPanelID is the panel a swipe moved from, or -1 if this isn't a swipe
+	SwipedFromPanelID int
+}
+
+// noPanel is used in touch packets to indicate there is no panel a swipe started from
+const noPanel = 0xFFFF
+
+// ParseTouchPacket decodes a packet of touch data sent by the panels
+func ParseTouchPacket(b []byte) ([]TouchEvent, error) {
+	if len(b) < 2 {
+		return nil, ErrMalformedTouchPacket
+	}
+
+	count := int(binary.BigEndian.Uint16(b))
+	if len(b) != 2+count*5 {
+		return nil, ErrMalformedTouchPacket
+	}
+
+	events := make([]TouchEvent, 0, count)
+	for i := 0; i < count; i++ {
+		entry := b[2+i*5:]
+
+		event := TouchEvent{
+			PanelID:           int(binary.BigEndian.Uint16(entry)),
+			Type:              TouchType(entry[2] >> 4),
+			Strength:          int(entry[2] & 0x0F),
+			SwipedFromPanelID: int(binary.BigEndian.Uint16(entry[3:])),
+		}
+		if event.SwipedFromPanelID == noPanel {
+			event.SwipedFromPanelID = -1
+		}
+
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// SubscribeTouch opens a UDP socket on the specified address (any free port if empty) and asks the panel to send
+// per-panel touch data to it, returning a channel of the decoded touch events along with a channel of the gesture
+// updates received on the touch event stream the panel requires to be open while sending touch data.
+// Both channels should be read (the gestures can be passed to a GestureDispatcher), as the event stream is
+// only read as quickly as the gestures are consumed.
+// The channels are closed when the context is cancelled or the event stream is terminated by the panel.
+func (c *Client) SubscribeTouch(ctx context.Context, listenAddr string) (<-chan TouchEvent, <-chan PanelUpdate, error) {
+	if len(listenAddr) < 1 {
+		listenAddr = ":0"
+	}
+
+	conn, err := net.ListenPacket("udp", listenAddr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	header := http.Header{}
+	header.Set("TouchEventsPort", strconv.Itoa(conn.LocalAddr().(*net.UDPAddr).Port))
+
+	ctx, cancel := context.WithCancel(ctx)
+	resp, err := c.openEvents(ctx, []EventType{EventTypeTouch}, header)
+	if err != nil {
+		cancel()
+		conn.Close()
+		return nil, nil, err
+	}
+
+	gestures := make(chan PanelUpdate)
+	go func() {
+		defer close(gestures)
+
+		readEvents(resp.Body, func(update PanelUpdate) bool {
+			select {
+			case gestures <- update:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+		resp.Body.Close()
+		cancel()
+	}()
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	events := make(chan TouchEvent)
+	go func() {
+		defer close(events)
+
+		buf := make([]byte, 2048)
+		for {
+			n, _, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+
+			touches, err := ParseTouchPacket(buf[:n])
+			if err != nil {
+				continue
+			}
+
+			for _, touch := range touches {
+				select {
+				case events <- touch:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return events, gestures, nil
+}
@@ -0,0 +1,168 @@
+package nanoleaf_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rmrobinson/nanoleaf-go"
+	"github.com/rmrobinson/nanoleaf-go/nanoleaftest"
+)
+
+// capturedTouchPacket holds a touch down on panel 0x1234 and a swipe onto panel 0x0042 from it
+var capturedTouchPacket = []byte{
+	0x00, 0x02,
+	0x12, 0x34, 0x1A, 0xFF, 0xFF,
+	0x00, 0x42, 0x47, 0x12, 0x34,
+}
+
+func TestParseTouchPacket(t *testing.T) {
+	tests := []struct {
+		name     string
+		packet   []byte
+		expected []nanoleaf.TouchEvent
+		err      error
+	}{
+		{
+			name:   "captured",
+			packet: capturedTouchPacket,
+			expected: []nanoleaf.TouchEvent{
+				{PanelID: 0x1234, Type: nanoleaf.TouchDown, Strength: 10, SwipedFromPanelID: -1},
+				{PanelID: 0x42, Type: nanoleaf.TouchSwipe, Strength: 7, SwipedFromPanelID: 0x1234},
+			},
+		},
+		{
+			name:     "empty",
+			packet:   []byte{0x00, 0x00},
+			expected: []nanoleaf.TouchEvent{},
+		},
+		{
+			name:   "hover",
+			packet: []byte{0x00, 0x01, 0x00, 0x07, 0x03, 0xFF, 0xFF},
+			expected: []nanoleaf.TouchEvent{
+				{PanelID: 7, Type: nanoleaf.TouchHover, Strength: 3, SwipedFromPanelID: -1},
+			},
+		},
+		{name: "no count", packet: []byte{0x00}, err: nanoleaf.ErrMalformedTouchPacket},
+		{name: "nil", err: nanoleaf.ErrMalformedTouchPacket},
+		{name: "truncated entry", packet: capturedTouchPacket[:len(capturedTouchPacket)-1], err: nanoleaf.ErrMalformedTouchPacket},
+		{name: "trailing data", packet: append(append([]byte(nil), capturedTouchPacket...), 0x00), err: nanoleaf.ErrMalformedTouchPacket},
+		{name: "count too high", packet: append([]byte{0x00, 0x03}, capturedTouchPacket[2:]...), err: nanoleaf.ErrMalformedTouchPacket},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			events, err := nanoleaf.ParseTouchPacket(test.packet)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("expected error %v, got %v", test.err, err)
+			} else if err == nil && !reflect.DeepEqual(events, test.expected) {
+				t.Fatalf("expected %+v, got %+v", test.expected, events)
+			}
+		})
+	}
+}
+
+// nextTouch returns the next touch event from the channel, failing the test if none arrives in time
+func nextTouch(t *testing.T, touches <-chan nanoleaf.TouchEvent) nanoleaf.TouchEvent {
+	t.Helper()
+
+	select {
+	case touch, ok := <-touches:
+		if !ok {
+			t.Fatal("touch channel closed")
+		}
+		return touch
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for touch event")
+	}
+	return nanoleaf.TouchEvent{}
+}
+
+func TestSubscribeTouchReceivesCapturedPackets(t *testing.T) {
+	controller := nanoleaftest.NewController()
+	defer controller.Close()
+
+	// Find a free port so the captured packets can be sent to it directly
+	probe, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening: %s", err)
+	}
+	addr := probe.LocalAddr().String()
+	probe.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	touches, _, err := controller.Client().SubscribeTouch(ctx, addr)
+	if err != nil {
+		t.Fatalf("error subscribing: %s", err)
+	}
+
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		t.Fatalf("error dialing: %s", err)
+	}
+	defer conn.Close()
+
+	// Malformed packets are skipped
+	conn.Write([]byte{0x00, 0x05, 0x01})
+	conn.Write(capturedTouchPacket)
+
+	if touch := nextTouch(t, touches); touch.PanelID != 0x1234 || touch.Type != nanoleaf.TouchDown || touch.SwipedFromPanelID != -1 {
+		t.Fatalf("unexpected first touch %+v", touch)
+	}
+	if touch := nextTouch(t, touches); touch.PanelID != 0x42 || touch.Type != nanoleaf.TouchSwipe || touch.SwipedFromPanelID != 0x1234 {
+		t.Fatalf("unexpected second touch %+v", touch)
+	}
+}
+
+func TestSubscribeTouch(t *testing.T) {
+	controller := nanoleaftest.NewController()
+	defer controller.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	touches, gestures, err := controller.Client().SubscribeTouch(ctx, "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error subscribing: %s", err)
+	}
+
+	sent := nanoleaf.TouchEvent{PanelID: 101, Type: nanoleaf.TouchHold, Strength: 15, SwipedFromPanelID: -1}
+
+	// The controller only knows where to send touch data once the event stream is open
+	deadline := time.Now().Add(5 * time.Second)
+	var received nanoleaf.TouchEvent
+	for received != sent {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for touch data")
+		}
+		if err = controller.SendTouches(sent); err != nil {
+			t.Fatalf("error sending touches: %s", err)
+		}
+
+		select {
+		case received = <-touches:
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+
+	controller.SendGestures(nanoleaf.Gesture{GestureType: nanoleaf.GestureSwipeUp, PanelID: 102})
+	select {
+	case update := <-gestures:
+		expected := []nanoleaf.Gesture{{GestureType: nanoleaf.GestureSwipeUp, PanelID: 102}}
+		if update.TypeID != int(nanoleaf.EventTypeTouch) || !reflect.DeepEqual(update.Gestures, expected) {
+			t.Fatalf("expected gestures %+v, got %+v", expected, update)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for gesture")
+	}
+
+	cancel()
+	for range touches {
+	}
+	for range gestures {
+	}
+}